@@ -0,0 +1,55 @@
+package clin
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Case identifies a letter case conversion applied to each token.
+type Case int
+
+// Constants defining each supported Case.
+const (
+	// CaseNone is the default, and tokens are not converted.
+	CaseNone Case = iota
+	// CaseLower converts all letters to lower case.
+	CaseLower
+	// CaseUpper converts all letters to upper case.
+	CaseUpper
+	// CaseTitle converts the first letter of each word to title case, and all
+	// other letters to lower case. Words are separated by white space.
+	CaseTitle
+)
+
+// convert returns s converted to the receiver Case.
+func (c Case) convert(s string) string {
+	switch c {
+	case CaseLower:
+		return strings.ToLower(s)
+	case CaseUpper:
+		return strings.ToUpper(s)
+	case CaseTitle:
+		return title(s)
+	}
+	return s
+}
+
+// title returns s with the first letter of each word mapped to title case,
+// and all other letters mapped to lower case.
+func title(s string) string {
+	var b strings.Builder
+	b.Grow(len(s))
+	start := true
+	for _, r := range s {
+		switch {
+		case unicode.IsSpace(r):
+			start = true
+		case start:
+			r, start = unicode.ToTitle(r), false
+		default:
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,32 @@
+package clin
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestInputCase(t *testing.T) {
+
+	const content = "Foo\nfoo bAR\nÉcole  élève\nFOO\n"
+	for _, tt := range []struct {
+		c      Case
+		unique bool
+		want   []string
+	}{
+		{c: CaseNone, want: []string{"Foo", "foo bAR", "École  élève", "FOO"}},
+		{c: CaseLower, want: []string{"foo", "foo bar", "école  élève", "foo"}},
+		{c: CaseUpper, want: []string{"FOO", "FOO BAR", "ÉCOLE  ÉLÈVE", "FOO"}},
+		{c: CaseTitle, want: []string{"Foo", "Foo Bar", "École  Élève", "Foo"}},
+		{c: CaseNone, unique: true, want: []string{"Foo", "foo bAR", "École  élève", "FOO"}},
+		{c: CaseLower, unique: true, want: []string{"foo", "foo bar", "école  élève"}},
+	} {
+		in := Default()
+		in.Case = tt.c
+		in.Unique = tt.unique
+		in.Stream = strings.NewReader(content)
+		if got := in.Args([]string{}); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Case=%d, Unique=%t: Args() = %q, want %q", tt.c, tt.unique, got, tt.want)
+		}
+	}
+}
@@ -4,11 +4,11 @@
 // It is lightweight (uses packages from the standard library only) and easily
 // integrates with complex flag parsing packages like "flag".
 //
-// There is one type, Input, and three of its methods exported, Args, Fields,
-// and Reader. See the godoc comments on each of those methods for details.
+// The primary type is Input, whose primary methods are Args, Fields, and
+// Reader. See the godoc comments on each of those methods for details.
 //
 // A global unexported variable of type Input is also defined, which is the
-// target of top-level functions Args, Fields, and Reader.
+// target of all top-level package functions (e.g., Args, Fields, and Reader).
 // The function Default returns an Input initialized with the value of this
 // global variable, whose fields can then be modified to fine-tune the behavior
 // of each method.
@@ -18,9 +18,27 @@ package clin
 
 import (
 	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
+	"io/fs"
+	"iter"
+	"net/http"
+	"net/url"
 	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
 )
 
 // Input configures the behavior of its exported functions Args and Reader.
@@ -29,43 +47,458 @@ import (
 //
 // To configure different behavior, either make a different Input or use
 // function Default to start with an Input with default configuration.
+//
+// No method of Input except Reset modifies the receiver, so a single Input may
+// be used by multiple goroutines concurrently, provided that Reset is not
+// called concurrently and any Stream read by those goroutines is itself safe
+// for concurrent use.
 type Input struct {
 	// The default reader to read from when no arguments are given (typically
 	// os.Stdin for command-line applications).
+	// A nil Stream is treated as empty, so that, e.g., Args returns no tokens
+	// and Reader returns a reader at EOF, as when Input is constructed without
+	// using Default.
 	Stream io.Reader
+	// The writer to which interactive prompts are written (typically os.Stderr,
+	// so that prompts do not mix with a program's regular output).
+	Out io.Writer
 	// If true, always interpret input as a string literal, never a file path.
 	Literal bool
+	// If true, a single argument "-" refers to Stream, as if no arguments were
+	// given, following the convention of many command-line utilities (e.g.,
+	// "cat -"). This takes precedence over Literal. Otherwise, "-" is handled
+	// like any other argument.
+	DashStdin bool
+	// The encoding of the content returned by Reader, which is decoded before
+	// it is returned. This applies to files, string literals, and Stream alike.
+	// Invalid input is reported as an error when reading from Reader.
+	// The default DecodeNone performs no conversion.
+	Decode Decoding
+	// If true, each CR+LF ("\r\n") and each lone carriage return ("\r") in the
+	// content returned by Reader is converted to a newline ("\n"), after
+	// decoding with Decode. Like Decode, this applies to files, string literals,
+	// and Stream alike.
+	NormalizeNewlines bool
+	// If non-nil, the content returned by Reader is replaced according to
+	// Replace (e.g., to convert tabs to spaces), after NormalizeNewlines.
+	// Because a match may span any number of reads, the entire content is read
+	// into memory on the first Read, and then replaced all at once. Like Decode,
+	// this applies to files, string literals, and Stream alike.
+	Replace *strings.Replacer
+	// If non-nil, Progress is called periodically while reading the content
+	// returned by Reader (e.g., by Copy), with the number of bytes read so far
+	// and the total number of bytes expected. The total is the size of the file
+	// or string literal being read, or -1 if it is unknown (e.g., when reading
+	// a pipe, a URL, multiple files, or decompressed content).
+	// Calls are made at most once every progressInterval, plus once more when
+	// the end of the content is reached.
+	Progress func(bytesRead, total int64)
+	// If true, when Reader opens a file whose content begins with the magic
+	// bytes of a gzip, bzip2, or zlib stream, the decompressed content is read
+	// instead. Files with an invalid header are read as-is.
+	AutoDecompress bool
+	// If true, when Reader is given a single argument that is not the path of
+	// an existing file, but is a glob pattern (as defined by filepath.Match)
+	// matching one or more files, then the content of all matching files is
+	// read, concatenated in the lexical order returned by filepath.Glob.
+	// If the pattern matches no files, the argument is read as a string.
+	ExpandGlob bool
+	// If true, when Reader is given more than one argument and every argument
+	// is the path of an existing file, then the content of all files is read,
+	// concatenated in the given order (like "cat file1 file2").
+	// Otherwise, the arguments are joined and read as a string.
+	MultiFile bool
+	// If true, when Reader is given a single argument that is an absolute HTTP
+	// or HTTPS URL, the body of the response to a GET request for that URL is
+	// read, using http.DefaultClient.
+	// A response with a non-2xx status code is reported as an error by
+	// ReaderErr and ReaderCloser.
+	AllowURL bool
+	// If true, when Reader is given a single argument beginning with "~" (either
+	// alone, or followed by a path separator), the "~" is replaced by the
+	// current user's home directory before attempting to open it as a file.
+	// If the home directory cannot be determined, or the expanded path refers
+	// to no file, the argument is read as a string, unmodified.
+	ExpandTilde bool
+	// If true, when Reader is given a single argument that is a non-negative
+	// decimal integer less than 65536 (e.g., "3"), the argument is interpreted
+	// as an open file descriptor, and the content read from that descriptor is
+	// returned. The descriptor remains owned by the caller: it is duplicated,
+	// and only the duplicate is closed, either by closing the io.ReadCloser
+	// returned by ReaderCloser or when the reader returned by Reader is garbage
+	// collected. If the descriptor is not open, or on platforms other than Unix
+	// (where descriptors cannot be duplicated), the argument is handled as if
+	// FDArg were false.
+	FDArg bool
+	// If non-nil, OpenFunc is used instead of os.Open to open the file named
+	// by a single argument given to Reader (or the path given to ArgsFromFile),
+	// e.g., to read from a virtual file system. The argument refers to a file
+	// if OpenFunc returns no error. If OpenFunc returns an error satisfying
+	// errors.Is(err, fs.ErrNotExist), the argument is read as a string;
+	// any other error is returned by ReaderErr.
+	// ExpandGlob does not apply, and AutoDecompress applies only if OpenFunc
+	// returns an *os.File. Source also calls OpenFunc (and closes the result
+	// without reading it) to determine whether the argument refers to a file.
+	OpenFunc func(name string) (io.ReadCloser, error)
+	// If true, ArgsErr, ReaderErr, and ReaderCloser return ErrTerminal instead
+	// of reading from Stream when no arguments are given and Stream is a
+	// terminal. Args then returns no tokens, and Reader returns an empty
+	// reader. This lets a program print usage instead of blocking on
+	// interactive input. Only a Stream of type *os.File is inspected.
+	// On Unix, only a terminal recognized by the termios ioctl counts, so
+	// other character devices (e.g., os.DevNull, as used by cron and nohup) are
+	// read normally. Elsewhere, any character device is a terminal, as reported
+	// by IsTerminal.
+	ErrorOnTTY bool
+	// The field delimiter used by ArgsCSV. If zero, a comma (',') is used.
+	CSVComma rune
+	// If true, Pairs returns ErrMissingEquals for any non-empty token that does
+	// not contain "=". Otherwise, such tokens are keys with an empty value.
+	RequireEquals bool
 	// When Args scans Stream for elements of the returned slice, the input
 	// stream is tokenized using ArgsDelim as separator.
 	ArgsDelim []byte
+	// The separator between records read by Records, each of which is then
+	// tokenized using ArgsDelim. If empty, records are separated by a blank
+	// line, with either LF ("\n\n") or CR+LF ("\r\n\r\n") line endings.
+	RecordDelim []byte
+	// Additional separators used along with ArgsDelim to tokenize Stream.
+	// A token ends at the first occurrence of any of these delimiters. If more
+	// than one delimiter matches at the same position, the longest is used.
+	MultiDelim [][]byte
+	// If true, Args tokenizes Stream using shell-style quoting and escapes
+	// instead of ArgsDelim, MultiDelim, and DelimRunes. Tokens are separated by
+	// unquoted white space, and may contain white space by enclosing it in
+	// single or double quotes, or by escaping it with a backslash.
+	// Reaching the end of Stream within a quoted string is reported by ArgsErr
+	// as ErrUnterminatedQuote.
+	ShellSplit bool
+	// If non-nil, Args tokenizes Stream using Split (e.g., bufio.ScanWords)
+	// instead of ArgsDelim, MultiDelim, DelimRunes, or ShellSplit. The options
+	// that affect how delimiters are recognized (e.g., KeepCR and
+	// KeepFinalEmpty) do not apply, so Split alone decides which tokens,
+	// including empty tokens, are returned. Per-token options (e.g., TrimSpace)
+	// are still applied to each token.
+	Split bufio.SplitFunc
+	// Additional separators used along with ArgsDelim and MultiDelim to
+	// tokenize Stream. A token ends at the first occurrence of any rune in
+	// DelimRunes.
+	// Consecutive delimiters are not collapsed; each produces an empty token,
+	// as with ArgsDelim. Set ArgsDelim to nil to split on DelimRunes only.
+	DelimRunes []rune
+	// If true, Args keeps the final empty token that results from Stream ending
+	// with a delimiter, which is otherwise discarded. Tokens then correspond
+	// exactly to the result of strings.Split; e.g., "a\nb\n" yields "a", "b",
+	// and "", and an empty Stream yields a single empty token.
+	// Fields still removes the empty token regardless of KeepFinalEmpty.
+	KeepFinalEmpty bool
+	// If true, WriteArgs writes ArgsDelim after the final token as well as
+	// between tokens, so that, e.g., newline-delimited output ends with a
+	// newline.
+	WriteFinalDelim bool
+	// If non-nil, a line describing each decision made while tokenizing Stream
+	// with ArgsDelim, MultiDelim, or DelimRunes is written to Debug (e.g., the
+	// delimiter found and its offset, a "\r" removed before a newline, or a final
+	// empty token discarded). Offsets are relative to the beginning of the
+	// token being scanned. It does not apply to ShellSplit or Split.
+	Debug io.Writer
+	// A carriage return ("\r") immediately preceding any delimiter that begins
+	// with a newline ("\n") is removed from the token, which transparently
+	// handles Windows/DOS (CR+LF) line endings. If KeepCR is true, it is kept
+	// as part of the token instead.
+	KeepCR bool
+	// If non-zero, a delimiter (ArgsDelim, MultiDelim, or DelimRunes) that is
+	// immediately preceded by EscapeChar is part of the token instead of ending
+	// it, and the EscapeChar is removed (e.g., with delimiter ',' and escape
+	// '\\', the input `a\,b,c` contains tokens "a,b" and "c"). Likewise, two
+	// consecutive EscapeChar are replaced by one, so that a token can end with
+	// EscapeChar. An EscapeChar followed by anything else, or at the end of
+	// Stream, is kept as-is. It does not apply to ShellSplit or Split.
+	EscapeChar byte
+	// If true, each run of consecutive delimiters separates two tokens as if it
+	// were a single delimiter, and leading delimiters are ignored, so that Args
+	// never returns an empty token read from Stream (e.g., ",a,,b," contains
+	// tokens "a" and "b" with delimiter ','). This takes precedence over
+	// KeepFinalEmpty. Unlike Fields, tokens that become empty only after
+	// per-token processing (e.g., TrimSpace) are still returned.
+	CollapseDelims bool
 	// When Reader returns a strings.NewReader over the given slice args,
 	// the elements of args are joined together, with ReadDelim as separator.
 	ReadDelim []byte
-	// Discard final Scanner token, if empty, when reading Stream in Args.
-	skipToken bool
+	// If non-nil, JoinFunc is used instead of ReadDelim to join more than one
+	// element of args into the string read by Reader. This can be used, e.g.,
+	// to quote each element so that the result can be parsed again.
+	// A single element is always read as-is.
+	JoinFunc func([]string) string
+	// If true, remove all leading and trailing white space from each token
+	// returned by Args, as defined by strings.TrimSpace.
+	TrimSpace bool
+	// If non-empty, remove all leading and trailing Unicode code points
+	// contained in TrimCutset from each token returned by Args, as defined by
+	// strings.Trim (e.g., ",;" removes commas and semicolons). It is applied
+	// after TrimSpace, so white space that remains between cutset characters
+	// (e.g., in ", a ;") is removed only if it is also contained in TrimCutset.
+	TrimCutset string
+	// If non-empty, each token returned by Args is discarded if it begins with
+	// CommentPrefix, ignoring any leading white space.
+	CommentPrefix []byte
+	// If non-empty, the first occurrence of InlineCommentPrefix in each token
+	// returned by Args, and everything following it, is removed before any
+	// other per-token processing (e.g., TrimSpace), so that "abc # note" yields
+	// "abc " with prefix "#". A token that begins with InlineCommentPrefix
+	// becomes empty rather than being discarded as with CommentPrefix.
+	// It is not applied with ShellSplit, because quotes have already been
+	// removed from each token, and a prefix that was quoted (and therefore not
+	// a comment) can no longer be distinguished from one that was not.
+	InlineCommentPrefix []byte
+	// If non-empty, a single leading TrimPrefix and a single trailing TrimSuffix
+	// are removed from each token returned by Args, if present, as with
+	// strings.TrimPrefix and strings.TrimSuffix. They are applied after
+	// TrimSpace and TrimCutset.
+	TrimPrefix []byte
+	TrimSuffix []byte
+	// If true, references to environment variables ($var or ${var}) in each
+	// token returned by Args, and in each argument that Reader reads as a
+	// string, are replaced according to os.ExpandEnv. Undefined variables are
+	// replaced with the empty string. The content of files is never expanded.
+	ExpandEnv bool
+	// The letter case to which each token returned by Args is converted, after
+	// ExpandEnv and before Transform. Tokens are converted before they are
+	// compared for Unique, so that, e.g., "Foo" and "foo" are duplicates with
+	// CaseLower. The default CaseNone performs no conversion.
+	Case Case
+	// If non-nil, each token returned by Args is replaced with the result of
+	// calling Transform on that token, after all other per-token options have
+	// been applied.
+	Transform func(string) string
+	// If greater than zero, Args stops reading after MaxTokens tokens have been
+	// collected.
+	// Any remaining content in Stream is left unread, with the exception of data
+	// already buffered internally by the scanner.
+	MaxTokens int
+	// If non-nil, each token returned by Args must match Validate, after all
+	// other per-token processing (e.g., TrimSpace). Reading stops at the first
+	// token that does not match, and ArgsErr returns an error wrapping
+	// ErrInvalidToken that identifies the token and its position.
+	Validate *regexp.Regexp
+	// If true, each token returned by Args that is equal to a token already
+	// returned is discarded, so that only the first occurrence is kept.
+	// Tokens are compared after all other per-token processing (e.g.,
+	// TrimSpace), and discarded tokens do not count toward MaxTokens.
+	Unique bool
+	// If true, reading stops at the first token returned by Args that is equal
+	// to a token already returned, and ArgsErr returns an error wrapping
+	// ErrDuplicateToken that identifies the token and its position.
+	// Tokens are compared as with Unique. RejectDuplicates takes precedence
+	// over Unique, so that duplicates are reported rather than discarded when
+	// both are true.
+	RejectDuplicates bool
+	// If true, each empty token returned by Args before the first non-empty
+	// token is discarded, while empty tokens that follow it are kept (unlike
+	// Fields, which removes all empty tokens). Tokens are compared after all
+	// per-token processing, so with TrimSpace, a leading token containing only
+	// white space is also discarded.
+	SkipLeadingEmpty bool
+	// If true, the tokens returned by Args (and ArgsErr, ArgsContext, and
+	// Fields) are sorted in increasing lexical order, after all other
+	// processing (e.g., TrimSpace and Unique).
+	// Methods that deliver tokens as they are read (e.g., ArgsSeq, ArgsChan,
+	// Cursor, Scan, ArgsN, ArgsNumbered, and FirstOr) cannot sort, so they
+	// always deliver tokens in input order.
+	Sort bool
+	// If non-nil, the tokens returned by Args are sorted as with Sort, but
+	// ordered by SortFunc instead, which reports whether a must sort before b.
+	// Tokens that are equal according to SortFunc keep their original order.
+	SortFunc func(a, b string) bool
+	// If true, ArgsErr and ArgsContext return ErrNoInput when args is empty and
+	// no tokens are read from Stream (after all processing, e.g., CommentPrefix),
+	// so that a program can report its usage instead of proceeding without
+	// input. Args still returns an empty slice.
+	RequireInput bool
+	// If non-nil, IsEmpty reports whether a token is empty, replacing the
+	// default definition (the empty string) used by Fields and the methods
+	// derived from it (e.g., FieldsIndexed, FirstOr, and ArgsSet). For example,
+	// it may also treat "-" or "null" as empty. It is called with each token
+	// after all per-token processing.
+	IsEmpty func(string) bool
+	// If greater than zero, the maximum size in bytes of any single token read
+	// from Stream. Otherwise, the default bufio.MaxScanTokenSize (64 KiB) is
+	// used. Reading a token larger than this limit stops the scan with error
+	// bufio.ErrTooLong, which is returned by ArgsErr.
+	MaxTokenBytes int
+	// If greater than zero, the initial capacity in bytes of the buffer used to
+	// scan tokens from Stream, which grows as needed up to MaxTokenBytes.
+	// A small buffer saves memory when reading little input, while a buffer
+	// larger than the input avoids reallocation. Otherwise, the buffer starts
+	// with the default capacity of bufio.Scanner.
+	InitialBufSize int
+	// If greater than zero, the maximum total number of bytes read from Stream.
+	// Once the limit is reached, reads fail with error ErrInputTooLarge if
+	// Stream contains more data. To detect this, at most one byte beyond the
+	// limit is read (and consumed) from Stream; nothing beyond that is read.
+	// Tokens are truncated at the limit: Args returns each token read so far,
+	// including the partial token at the limit, and ArgsErr returns the same
+	// tokens along with ErrInputTooLarge. Likewise, when Reader returns Stream,
+	// reading beyond the limit fails with ErrInputTooLarge.
+	// Stream is not affected if it contains no more than MaxBytes bytes.
+	MaxBytes int64
+	// If greater than zero, the number of times a failed Read from Stream is
+	// retried by Args before the error is returned, waiting a short while
+	// (doubling with each attempt, up to one second) before each retry.
+	// A Read that returns io.EOF is never retried, and the count is reset by
+	// each successful Read.
+	// Whether data is lost or duplicated by a failed Read depends on Stream;
+	// for example, a retried Read can return part of a token again.
+	RetryReads int
+	// If greater than zero, ArgsErr and ArgsContext stop reading from Stream if
+	// no token is read within IdleTimeout, either initially or since the last
+	// token, and return the tokens read so far along with ErrIdleTimeout.
+	// As with ArgsContext, the pending Read on Stream is left outstanding.
+	IdleTimeout time.Duration
+	// If non-nil, all data read from Stream by Args or Reader is also written to
+	// Tee as it is read (e.g., to log exactly what was consumed), before any
+	// conversion (e.g., Encoding or Decode). Data read from files, URLs, or
+	// string literals is not written to Tee.
+	// An error writing to Tee is returned as a read error.
+	Tee io.Writer
+	// If true, discard the UTF-8 byte order mark ("\xEF\xBB\xBF") if present at
+	// the very beginning of Stream, before it is tokenized by Args.
+	StripBOM bool
+	// The character encoding of Stream, which is converted to UTF-8 before it
+	// is tokenized by Args. The default UTF8 performs no conversion.
+	// It is applied before StripBOM.
+	Encoding Encoding
+	// If non-nil, records the content of Stream that has been read but not yet
+	// tokenized by scan.
+	unread *remainder
 }
 
-// input defines the default configuration and is the target of top-level
-// functions Args, Fields, and Reader.
+// input defines the default configuration and is the target of all top-level
+// package functions.
 var input = Input{
 	Stream:    os.Stdin,
+	Out:       os.Stderr,
 	Literal:   false,
 	ArgsDelim: []byte("\n"),
 	ReadDelim: []byte(" "),
+	CSVComma:  ',',
 }
 
 // Default returns an Input with default configuration.
 func Default() Input { return input }
 
+// Reset restores every field of the receiver to its default configuration, as
+// returned by Default.
+func (in *Input) Reset() { *in = input }
+
+// DefaultNUL returns an Input with default configuration, except that Stream
+// is tokenized using the NUL byte ("\x00") as separator.
+// This is the format produced by commands like "find -print0" and consumed by
+// "xargs -0", which permits tokens containing newlines.
+func DefaultNUL() Input {
+	in := Default()
+	in.ArgsDelim = []byte{0}
+	return in
+}
+
+// Merge returns an Input whose Stream reads the Stream of each of the given
+// inputs in order, as with io.MultiReader.
+// Every other field of the result, including ArgsDelim, is copied from the
+// first input; the settings of the remaining inputs are ignored, so all merged
+// streams are tokenized using the delimiters of the first. Inputs with a nil
+// Stream are skipped.
+// Note that streams are concatenated as-is, so a stream that does not end with
+// a delimiter is joined with the first token of the stream that follows it.
+// If no inputs are given, Merge returns Default().
+func Merge(inputs ...Input) Input {
+	if len(inputs) == 0 {
+		return Default()
+	}
+	merged := inputs[0]
+	r := make([]io.Reader, 0, len(inputs))
+	for _, in := range inputs {
+		if in.Stream != nil {
+			r = append(r, in.Stream)
+		}
+	}
+	merged.Stream = io.MultiReader(r...)
+	return merged
+}
+
+// ErrTerminal is returned when ErrorOnTTY is true and input would be read from
+// a terminal.
+var ErrTerminal = errors.New("clin: input stream is a terminal")
+
+// ErrMissingEquals is returned by Pairs when RequireEquals is true and a token
+// is not of the form key=value.
+var ErrMissingEquals = errors.New("clin: missing '=' in key=value pair")
+
+// ErrInvalidToken is returned when Validate is non-nil and a token does not
+// match it, or when Scan cannot convert a token to its destination type.
+var ErrInvalidToken = errors.New("clin: invalid token")
+
+// ErrDuplicateToken is returned when RejectDuplicates is true and a token is
+// equal to a token read before it.
+var ErrDuplicateToken = errors.New("clin: duplicate token")
+
+// ErrIsDirectory is returned by ReaderErr when the single argument is a
+// directory, which cannot be read as a file.
+var ErrIsDirectory = errors.New("clin: is a directory")
+
+// ErrInputTooLarge is returned when MaxBytes is positive and Stream contains
+// more than MaxBytes bytes.
+var ErrInputTooLarge = errors.New("clin: input exceeds MaxBytes")
+
+// ErrNoInput is returned when RequireInput is true and no tokens are read from
+// Stream, or when Single finds no token.
+var ErrNoInput = errors.New("clin: no input")
+
+// ErrTooManyInputs is returned by Single when there is more than one token.
+var ErrTooManyInputs = errors.New("clin: more than one input")
+
+// ErrIdleTimeout is returned when IdleTimeout is positive and no token is read
+// from Stream within that duration.
+var ErrIdleTimeout = errors.New("clin: idle timeout reading input")
+
+// IsTerminal reports whether f refers to a character device, such as an
+// interactive terminal, rather than a pipe or regular file.
+//
+// Only the file mode reported by f.Stat is examined, so other character
+// devices (e.g., os.DevNull) are also reported as terminals.
+func IsTerminal(f *os.File) bool {
+	if f == nil {
+		return false
+	}
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
 // Args returns the given string slice args if non-empty.
 // Otherwise, a slice of each token read from Stream is returned, delimited by
 // both CR+LF ("\r\n") and LF ("\n").
 func Args(args []string) []string { return input.Args(args) }
 
+// ArgsErr is like Args, but also returns any error encountered while reading
+// from Stream.
+func ArgsErr(args []string) ([]string, error) { return input.ArgsErr(args) }
+
+// ArgsContext is like ArgsErr, but stops reading from Stream when the given
+// context is done.
+func ArgsContext(ctx context.Context, args []string) ([]string, error) {
+	return input.ArgsContext(ctx, args)
+}
+
+// ArgsSeq returns an iterator over the tokens returned by Args, in input order.
+func ArgsSeq(args []string) iter.Seq[string] { return input.ArgsSeq(args) }
+
 // Fields wraps Args, and removes all empty (zeroed) string elements in the
 // returned slice.
 func Fields(args []string) []string { return input.Fields(args) }
 
+// FieldsTrim is like Fields, but also removes all elements that contain only
+// white space.
+func FieldsTrim(args []string) []string { return input.FieldsTrim(args) }
+
 // Reader returns an io.Reader over the string constructed by joining all
 // elements in the given non-empty slice args, separated by one space (" ").
 // If the given args contains a single element, and that element refers to
@@ -74,39 +507,973 @@ func Fields(args []string) []string { return input.Fields(args) }
 // Otherwise, args is empty, returns Stream.
 func Reader(args []string) io.Reader { return input.Reader(args) }
 
+// ReaderErr is like Reader, but returns an error if a file that exists could
+// not be opened, instead of reading the string itself.
+func ReaderErr(args []string) (io.Reader, error) { return input.ReaderErr(args) }
+
+// ReaderCloser is like Reader, but returns an io.ReadCloser that must be closed
+// by the caller to release any file opened on its behalf.
+func ReaderCloser(args []string) (io.ReadCloser, error) {
+	return input.ReaderCloser(args)
+}
+
+// ReaderFS is like ReaderErr, but a single argument is resolved as a file in
+// fsys instead of the operating system's file system.
+func ReaderFS(fsys fs.FS, args []string) (io.Reader, error) {
+	return input.ReaderFS(fsys, args)
+}
+
+// Joined returns the entire content of the reader returned by Reader as a
+// string.
+func Joined(args []string) (string, error) { return input.Joined(args) }
+
+// ReadAll returns the entire content of the reader returned by Reader.
+func ReadAll(args []string) ([]byte, error) { return input.ReadAll(args) }
+
+// Copy copies the entire content of the reader returned by Reader to w.
+func Copy(args []string, w io.Writer) (int64, error) { return input.Copy(args, w) }
+
 // Args returns the given string slice args if non-empty.
 // Otherwise, a slice of each token read from Stream is returned, delimited by
 // ArgsDelim.
+// In either case, each token is then processed according to the options
+// configured in Input, such as TrimSpace.
 func (in *Input) Args(args []string) []string {
-	if len(args) == 0 {
+	a, _ := in.ArgsErr(args)
+	return a
+}
+
+// ArgsErr is like Args, but also returns the first non-EOF error encountered
+// while scanning Stream, or the error from any option that rejects a token
+// (e.g., Validate).
+// The returned slice contains all tokens read before the error occurred.
+func (in *Input) ArgsErr(args []string) ([]string, error) {
+	if in.IdleTimeout > 0 && in.isStream(args) {
+		return in.ArgsContext(context.Background(), args)
+	}
+	a := make([]string, 0, len(args))
+	err := in.tokens(args, func(s string) bool {
+		a = append(a, s)
+		return true
+	})
+	return in.finish(args, a, err)
+}
+
+// finish returns the tokens a read by ArgsErr from args, sorted according to
+// Sort and SortFunc, along with err. If err is nil, RequireInput is true, and
+// no tokens were read from Stream, returns ErrNoInput instead.
+func (in *Input) finish(args, a []string, err error) ([]string, error) {
+	if err == nil && in.RequireInput && len(a) == 0 && in.isStream(args) {
+		err = ErrNoInput
+	}
+	return in.sort(a), err
+}
+
+// sort sorts a according to Sort and SortFunc, and returns a.
+func (in *Input) sort(a []string) []string {
+	switch {
+	case in.SortFunc != nil:
+		sort.SliceStable(a, func(i, j int) bool { return in.SortFunc(a[i], a[j]) })
+	case in.Sort:
+		sort.Strings(a)
+	}
+	return a
+}
+
+// ArgsContext is like ArgsErr, but returns early with the tokens read so far
+// and ctx.Err() if ctx is done before Stream has been read completely.
+//
+// Stream is read in a separate goroutine, because a blocked Read cannot be
+// interrupted in general. When ctx is done, ArgsContext returns immediately,
+// but the pending Read on Stream is left outstanding. The goroutine exits as
+// soon as that Read returns, and any data it delivers is discarded.
+func (in *Input) ArgsContext(ctx context.Context, args []string) ([]string, error) {
+	if !in.isStream(args) {
+		return in.ArgsErr(args)
+	}
+	if err := ctx.Err(); err != nil {
+		return []string{}, err
+	}
+	// Stop the scanning goroutine when returning for any reason (e.g.,
+	// IdleTimeout), even if the caller's ctx is never done.
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	tok := make(chan string)
+	end := make(chan error, 1)
+	go func() {
+		end <- in.tokens(args, func(s string) bool {
+			select {
+			case tok <- s:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		})
+	}()
+	var idle *time.Timer
+	var timeout <-chan time.Time
+	if in.IdleTimeout > 0 {
+		idle = time.NewTimer(in.IdleTimeout)
+		defer idle.Stop()
+		timeout = idle.C
+	}
+	a := []string{}
+	for {
+		select {
+		case s := <-tok:
+			a = append(a, s)
+			if idle != nil {
+				idle.Reset(in.IdleTimeout)
+			}
+		case <-timeout:
+			return in.sort(a), ErrIdleTimeout
+		case err := <-end:
+			return in.finish(args, a, err)
+		case <-ctx.Done():
+			return in.sort(a), ctx.Err()
+		}
+	}
+}
+
+// ArgsChan returns a channel that receives each token returned by Args as it is
+// scanned, in input order (Sort and SortFunc are not applied), and a channel
+// that receives the terminal error of the scan.
+//
+// Tokens are sent from a separate goroutine, so that a consumer may begin
+// processing before the whole input has been read. When all tokens have been
+// sent, the token channel is closed, and then the error channel receives the
+// first non-EOF error encountered while scanning Stream (or nil) before it is
+// also closed.
+//
+// If ctx is done before all tokens are sent, the goroutine stops sending and
+// the error channel receives ctx.Err(). A consumer that stops receiving tokens
+// before the token channel is closed must cancel ctx so that the goroutine can
+// exit. As with ArgsContext, a Read on Stream that is already pending when ctx
+// is done must still return before the goroutine exits.
+func (in *Input) ArgsChan(ctx context.Context, args []string) (<-chan string, <-chan error) {
+	tok := make(chan string)
+	errc := make(chan error, 1)
+	go func() {
+		defer close(errc)
+		var stop error
+		err := in.tokens(args, func(s string) bool {
+			select {
+			case tok <- s:
+				return true
+			case <-ctx.Done():
+				stop = ctx.Err()
+				return false
+			}
+		})
+		close(tok)
+		if err == nil {
+			err = stop
+		}
+		errc <- err
+	}()
+	return tok, errc
+}
+
+// ArgsSeq returns an iterator over the tokens returned by Args, in input order
+// (Sort and SortFunc are not applied).
+// If args is empty, tokens are scanned from Stream one at a time as
+// the iterator advances, so the entire stream is never held in memory.
+// Any error encountered while reading Stream ends the iteration; use ArgsErr
+// to observe such errors.
+func (in *Input) ArgsSeq(args []string) iter.Seq[string] {
+	return func(yield func(string) bool) {
+		_ = in.tokens(args, yield)
+	}
+}
+
+// Cursor reads the tokens returned by Args one at a time, as requested by
+// calls to Next. A Cursor is created with Input.Cursor.
+type Cursor struct {
+	next func() (string, bool)
+	stop func()
+	err  error
+}
+
+// Cursor returns a Cursor over the tokens that would be returned by Args, in
+// input order (Sort and SortFunc are not applied).
+// If args is empty, tokens are scanned from Stream only as Next is called.
+// If the caller stops calling Next before it returns false, Stop must be
+// called to release the resources held by the Cursor.
+func (in *Input) Cursor(args []string) *Cursor {
+	c := &Cursor{}
+	c.next, c.stop = iter.Pull(func(yield func(string) bool) {
+		c.err = in.tokens(args, yield)
+	})
+	return c
+}
+
+// Next returns the next token and true, or the empty string and false if there
+// are no more tokens (or an error occurred; see Err).
+func (c *Cursor) Next() (string, bool) {
+	s, ok := c.next()
+	if !ok {
+		c.stop()
+	}
+	return s, ok
+}
+
+// Err returns the first error encountered while reading Stream, if any,
+// once Next has returned false.
+func (c *Cursor) Err() error { return c.err }
+
+// Stop ends the iteration and releases the resources held by c. Subsequent
+// calls to Next return false. Stop may be called more than once.
+func (c *Cursor) Stop() { c.stop() }
+
+// tokens calls yield for each token of the given non-empty slice args, or for
+// each token scanned from Stream if args is empty, until either yield returns
+// false or all tokens have been visited.
+// Each token is processed according to the options configured in Input before
+// it is passed to yield, and no more than MaxTokens tokens are visited.
+// Returns the first non-EOF error encountered while scanning Stream, if any.
+func (in *Input) tokens(args []string, yield func(string) bool) error {
+	return in.tokensAt(args, func(_ int, s string) bool { return yield(s) })
+}
+
+// tokensAt is like tokens, but also passes to yield the zero-based position of
+// each token in args or Stream, before any tokens were discarded.
+func (in *Input) tokensAt(args []string, yield func(int, string) bool) error {
+	n, pos := 0, -1
+	var seen map[string]struct{}
+	if in.Unique || in.RejectDuplicates {
+		seen = map[string]struct{}{}
+	}
+	var stop error
+	leading := in.SkipLeadingEmpty
+	emit := func(s string) bool {
+		pos++
+		s, ok := in.token(s)
+		if !ok || (leading && s == "") {
+			return true
+		}
+		leading = false
+		if in.Validate != nil && !in.Validate.MatchString(s) {
+			stop = fmt.Errorf("%w: token %d %q does not match %q",
+				ErrInvalidToken, pos+1, s, in.Validate)
+			return false
+		}
+		if seen != nil {
+			if _, dup := seen[s]; dup {
+				if in.RejectDuplicates {
+					stop = fmt.Errorf("%w: token %d %q", ErrDuplicateToken, pos+1, s)
+					return false
+				}
+				return true
+			}
+			seen[s] = struct{}{}
+		}
+		if !yield(pos, s) {
+			return false
+		}
+		n++
+		return in.MaxTokens <= 0 || n < in.MaxTokens
+	}
+	var err error
+	if in.isStream(args) {
 		// No arguments: read lines from stdin.
-		s := bufio.NewScanner(in.Stream)
-		a := []string{}
-		s.Split(in.scanArgs)
-		in.skipToken = false
-		for s.Scan() {
-			if !in.skipToken {
-				a = append(a, s.Text())
+		if err = in.checkTTY(); err != nil {
+			return err
+		}
+		err = in.scan(emit)
+	} else {
+		for _, s := range args {
+			if !emit(s) {
+				break
 			}
 		}
+	}
+	if err == nil {
+		err = stop
+	}
+	return err
+}
+
+// token returns the given token s after applying each of the per-token options
+// configured in Input, and reports whether the token should be kept.
+func (in *Input) token(s string) (string, bool) {
+	if len(in.CommentPrefix) > 0 &&
+		strings.HasPrefix(strings.TrimLeftFunc(s, unicode.IsSpace),
+			string(in.CommentPrefix)) {
+		return "", false
+	}
+	if len(in.InlineCommentPrefix) > 0 && !in.ShellSplit {
+		if i := strings.Index(s, string(in.InlineCommentPrefix)); i >= 0 {
+			s = s[:i]
+		}
+	}
+	if in.TrimSpace {
+		s = strings.TrimSpace(s)
+	}
+	if in.TrimCutset != "" {
+		s = strings.Trim(s, in.TrimCutset)
+	}
+	if len(in.TrimPrefix) > 0 {
+		s = strings.TrimPrefix(s, string(in.TrimPrefix))
+	}
+	if len(in.TrimSuffix) > 0 {
+		s = strings.TrimSuffix(s, string(in.TrimSuffix))
+	}
+	if in.ExpandEnv {
+		s = os.ExpandEnv(s)
+	}
+	s = in.Case.convert(s)
+	if in.Transform != nil {
+		s = in.Transform(s)
+	}
+	return s, true
+}
+
+// scan tokenizes Stream with scanArgs, calling yield for each token until
+// either yield returns false or Stream is exhausted.
+// Returns the first non-EOF error encountered, if any.
+func (in *Input) scan(yield func(string) bool) error {
+	r := in.stream()
+	split := bufio.SplitFunc(in.scanArgs)
+	switch {
+	case in.Split != nil:
+		split = in.Split
+	case in.ShellSplit:
+		split = scanShell
+	}
+	if in.unread != nil {
+		r, split = in.unread.track(r, split)
+	}
+	s := bufio.NewScanner(r)
+	if in.MaxTokenBytes > 0 || in.InitialBufSize > 0 {
+		limit := bufio.MaxScanTokenSize
+		if in.MaxTokenBytes > 0 {
+			limit = in.MaxTokenBytes
+		}
+		size := min(bufio.MaxScanTokenSize, limit)
+		if in.InitialBufSize > 0 {
+			size = min(in.InitialBufSize, limit)
+		}
+		s.Buffer(make([]byte, 0, size), limit)
+	}
+	s.Split(split)
+	for s.Scan() {
+		if !yield(s.Text()) {
+			break
+		}
+	}
+	return s.Err()
+}
+
+// ArgsOr returns the tokens returned by Args, or def if Args returns no tokens.
+// Note that a single empty token (e.g., when args contains only "") is not the
+// same as no tokens, and is returned instead of def.
+func (in *Input) ArgsOr(args []string, def []string) []string {
+	if a := in.Args(args); len(a) > 0 {
 		return a
 	}
-	return args
+	return def
+}
+
+// FirstOr returns the first non-empty token read, in input order (Sort and
+// SortFunc are ignored), or def if there is none. Reading stops at the first
+// such token, so the rest of Stream is not consumed.
+// As with Fields, a token containing only white space is not empty unless
+// TrimSpace is true.
+func (in *Input) FirstOr(args []string, def string) string {
+	first := def
+	_ = in.tokens(args, func(s string) bool {
+		if in.empty(s) {
+			return true
+		}
+		first = s
+		return false
+	})
+	return first
+}
+
+// Single returns the only non-empty token that would be returned by Fields.
+// Returns ErrNoInput if there is no such token, or an error wrapping
+// ErrTooManyInputs that identifies the second token if there is more than one.
+// Reading stops at the second non-empty token, so the rest of Stream is not
+// consumed.
+func (in *Input) Single(args []string) (string, error) {
+	var a []string
+	err := in.tokens(args, func(s string) bool {
+		if !in.empty(s) {
+			a = append(a, s)
+		}
+		return len(a) < 2
+	})
+	switch {
+	case err != nil:
+		return "", err
+	case len(a) == 0:
+		return "", ErrNoInput
+	case len(a) > 1:
+		return "", fmt.Errorf("%w: %q follows %q", ErrTooManyInputs, a[1], a[0])
+	}
+	return a[0], nil
+}
+
+// ArgsChunks returns the tokens returned by Args, grouped in order into chunks
+// of size tokens each, except the last chunk, which may contain fewer.
+// If size is not positive, all tokens are returned in a single chunk.
+// If there are no tokens, no chunks are returned.
+func (in *Input) ArgsChunks(args []string, size int) [][]string {
+	a := in.Args(args)
+	if size <= 0 {
+		size = max(len(a), 1)
+	}
+	chunks := make([][]string, 0, (len(a)+size-1)/size)
+	for len(a) > 0 {
+		n := min(size, len(a))
+		chunks, a = append(chunks, a[:n:n]), a[n:]
+	}
+	return chunks
+}
+
+// Records returns each record read from Stream, delimited by RecordDelim, as a
+// slice of the tokens that Args would return when reading that record from
+// Stream, delimited by ArgsDelim (e.g., paragraphs of newline-delimited
+// fields). If args is non-empty, each of its elements is a record instead.
+// Empty records (e.g., following a final RecordDelim) are discarded.
+//
+// Options that adjust Stream itself (e.g., Encoding and MaxBytes) apply once
+// to the entire Stream, while per-token options (e.g., TrimSpace and
+// MaxTokens) apply to the tokens of each record separately.
+// Returns the records read before the first error encountered, if any.
+func (in *Input) Records(args []string) ([][]string, error) {
+	records := args
+	if in.isStream(args) {
+		if err := in.checkTTY(); err != nil {
+			return nil, err
+		}
+		rec := Input{
+			Stream:        in.stream(),
+			ArgsDelim:     in.RecordDelim,
+			KeepCR:        in.KeepCR,
+			MaxTokenBytes: in.MaxTokenBytes,
+		}
+		if len(rec.ArgsDelim) == 0 {
+			rec.ArgsDelim = []byte("\n\n")
+			rec.MultiDelim = [][]byte{[]byte("\r\n\r\n")}
+		}
+		records = nil
+		if err := rec.scan(func(s string) bool {
+			records = append(records, s)
+			return true
+		}); err != nil {
+			return nil, err
+		}
+	}
+	sub := *in
+	sub.DashStdin, sub.RequireInput, sub.IdleTimeout = false, false, 0
+	sub.Tee, sub.MaxBytes, sub.RetryReads = nil, 0, 0
+	sub.Encoding, sub.StripBOM = UTF8, false
+	a := make([][]string, 0, len(records))
+	for _, r := range records {
+		if r == "" {
+			continue
+		}
+		sub.Stream = strings.NewReader(r)
+		fields, err := sub.ArgsErr([]string{})
+		if err != nil {
+			return a, err
+		}
+		a = append(a, fields)
+	}
+	return a, nil
+}
+
+// ArgsJSON is like ArgsErr, but if args is empty, Stream is decoded as a JSON
+// array of strings, whose elements are returned as tokens, instead of being
+// delimited by ArgsDelim.
+// Each element is processed according to the options configured in Input, as
+// with Args. Stream must contain exactly one JSON array (or null), optionally
+// surrounded by white space.
+func (in *Input) ArgsJSON(args []string) ([]string, error) {
+	if !in.isStream(args) {
+		return in.ArgsErr(args)
+	}
+	if err := in.checkTTY(); err != nil {
+		return nil, err
+	}
+	var a []string
+	dec := json.NewDecoder(in.stream())
+	if err := dec.Decode(&a); err != nil {
+		return nil, fmt.Errorf("clin: invalid JSON array of strings: %w", err)
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, errors.New("clin: invalid JSON array of strings: " +
+			"unexpected data after array")
+	}
+	if len(a) == 0 {
+		return []string{}, nil
+	}
+	// The elements are tokens, and never select Stream again (e.g., ["-"]).
+	sub := *in
+	sub.DashStdin = false
+	return sub.ArgsErr(a)
+}
+
+// ArgsCSV returns the records of CSV (RFC 4180) content read from the reader
+// returned by ReaderCloser, and then closes it. Fields are delimited by
+// CSVComma, which defaults to comma (',').
+//
+// Records are delimited by the CSV reader rather than by ArgsDelim, so quoted
+// fields may contain newlines. Records need not have the same number of
+// fields. No per-token options configured in Input are applied to fields.
+func (in *Input) ArgsCSV(args []string) ([][]string, error) {
+	rc, err := in.ReaderCloser(args)
+	if err != nil {
+		return nil, err
+	}
+	r := csv.NewReader(rc)
+	if in.CSVComma != 0 {
+		r.Comma = in.CSVComma
+	}
+	r.FieldsPerRecord = -1
+	a, err := r.ReadAll()
+	if cerr := rc.Close(); err == nil {
+		err = cerr
+	}
+	if a == nil {
+		a = [][]string{}
+	}
+	return a, err
+}
+
+// ArgsRaw is like ArgsErr, but also returns the raw input from which the tokens
+// were read.
+// If args is non-empty, raw is the elements of args joined by ReadDelim.
+// Otherwise, raw is every byte read from Stream, before any conversion (e.g.,
+// Encoding). If scanning stops before Stream is exhausted (e.g., due to
+// MaxTokens), raw may include data following the last token, since Stream is
+// read in blocks.
+// IdleTimeout is ignored, because a Read still pending after a timeout could
+// otherwise modify raw after it is returned.
+func (in *Input) ArgsRaw(args []string) (tokens []string, raw []byte, err error) {
+	if !in.isStream(args) {
+		tokens, err = in.ArgsErr(args)
+		return tokens, []byte(strings.Join(args, string(in.ReadDelim))), err
+	}
+	if err := in.checkTTY(); err != nil {
+		return nil, nil, err
+	}
+	var buf bytes.Buffer
+	sub := *in
+	sub.Stream = io.TeeReader(in.input(), &buf)
+	sub.IdleTimeout = 0
+	tokens, err = sub.ArgsErr([]string{})
+	return tokens, buf.Bytes(), err
+}
+
+// Count returns the number of tokens that would be returned by Args, without
+// retaining the tokens themselves.
+// Also returns the first non-EOF error encountered while scanning Stream.
+func (in *Input) Count(args []string) (int, error) {
+	n := 0
+	err := in.tokens(args, func(string) bool {
+		n++
+		return true
+	})
+	return n, err
+}
+
+// Stats returns the number of tokens that would be returned by Args, like
+// wc(1), along with the number of bytes of input from which they were read.
+// If args is non-empty, bytes is the length of the literal string formed from
+// args, as Reader reads it when args do not refer to a file (i.e., joined by
+// ReadDelim, or by JoinFunc). It is never the size of a file named by args,
+// since Args does not read files.
+// Otherwise, bytes is the number of bytes read from Stream, before any
+// conversion (e.g., Encoding), and Stream is read only once.
+func (in *Input) Stats(args []string) (tokens int, bytes int64, err error) {
+	if !in.isStream(args) {
+		bytes = in.literal(args).Size()
+		tokens, err = in.Count(args)
+		return tokens, bytes, err
+	}
+	if err := in.checkTTY(); err != nil {
+		return 0, 0, err
+	}
+	cr := &countReader{r: in.input()}
+	sub := *in
+	sub.Stream = cr
+	tokens, err = sub.Count([]string{})
+	return tokens, cr.n, err
+}
+
+// Lines returns each token read from the reader returned by ReaderCloser,
+// delimited by ArgsDelim, and then closes it.
+// Unlike Args, a single argument that refers to a file is never returned as-is;
+// the tokens of that file's content are returned instead.
+// Similarly, the tokens of a single literal argument (or of all arguments,
+// joined by ReadDelim) are returned.
+func (in *Input) Lines(args []string) ([]string, error) {
+	rc, err := in.ReaderCloser(args)
+	if err != nil {
+		return nil, err
+	}
+	// Tee and MaxBytes were already applied to Stream by ReaderCloser, and
+	// never apply to files or string literals.
+	sub := *in
+	sub.Stream = rc
+	sub.Tee, sub.MaxBytes = nil, 0
+	if !in.isStream(args) {
+		sub.RetryReads = 0
+	}
+	a, err := sub.ArgsErr([]string{})
+	if cerr := rc.Close(); err == nil {
+		err = cerr
+	}
+	return a, err
+}
+
+// ArgsFromFile returns the tokens read from the file at path, delimited by
+// ArgsDelim and processed as by ArgsErr, and then closes the file.
+// If path is empty, the tokens are read from Stream instead.
+// Unlike Lines, path is always opened as a file, and an error is returned if it
+// cannot be opened.
+func (in *Input) ArgsFromFile(path string) ([]string, error) {
+	if path == "" {
+		return in.ArgsErr([]string{})
+	}
+	r, c, err := in.openArg(path)
+	if err != nil {
+		return nil, err
+	}
+	// Options that apply only to Stream do not apply to the file.
+	sub := *in
+	sub.Stream = r
+	sub.Tee, sub.MaxBytes, sub.RetryReads = nil, 0, 0
+	a, err := sub.ArgsErr([]string{})
+	if cerr := c.Close(); err == nil {
+		err = cerr
+	}
+	return a, err
+}
+
+// Pairs returns a map of the key=value pairs in each token returned by ArgsErr.
+// Each token is split on its first "=", so that values may contain "=".
+// If a key occurs more than once, the last value is used. Empty tokens are
+// ignored, and if TrimSpace is true, white space is also removed from around
+// each key and value.
+//
+// Tokens that do not contain "=" are keys with an empty value, unless
+// RequireEquals is true, in which case an error wrapping ErrMissingEquals is
+// returned that identifies the offending token.
+func (in *Input) Pairs(args []string) (map[string]string, error) {
+	a, err := in.ArgsErr(args)
+	if err != nil {
+		return nil, err
+	}
+	m := make(map[string]string, len(a))
+	for _, s := range a {
+		if s == "" {
+			continue
+		}
+		key, val, ok := strings.Cut(s, "=")
+		if !ok && in.RequireEquals {
+			return nil, fmt.Errorf("%w: %q", ErrMissingEquals, s)
+		}
+		if in.TrimSpace {
+			key, val = strings.TrimSpace(key), strings.TrimSpace(val)
+		}
+		m[key] = val
+	}
+	return m, nil
+}
+
+// SplitFirst returns each token returned by Args split into two elements on the
+// first occurrence of sep, which is removed. The second element is the rest of
+// the token verbatim, so that, e.g., "a: b c" with sep ':' yields "a" and
+// " b c". If a token does not contain sep, the second element is empty.
+// Unlike Pairs, empty tokens are kept, and the order of tokens is preserved.
+func (in *Input) SplitFirst(args []string, sep byte) [][2]string {
+	a := in.Args(args)
+	p := make([][2]string, 0, len(a))
+	for _, s := range a {
+		key, val, _ := strings.Cut(s, string([]byte{sep}))
+		p = append(p, [2]string{key, val})
+	}
+	return p
+}
+
+// Scan reads successive tokens that would be returned by Args into successive
+// elements of dst, and returns the number of elements successfully assigned.
+// Each element of dst must be a pointer to a type supported by fmt.Sscan.
+// A *string receives its token verbatim, including any white space.
+//
+// If a token cannot be converted to its destination type, returns an error
+// wrapping ErrInvalidToken that identifies the offending token.
+// If there are fewer tokens than elements of dst, returns io.ErrUnexpectedEOF.
+// Tokens following the last element of dst are not read.
+func (in *Input) Scan(args []string, dst ...interface{}) (int, error) {
+	if len(dst) == 0 {
+		return 0, nil
+	}
+	n := 0
+	var stop error
+	err := in.tokens(args, func(s string) bool {
+		if err := scanToken(s, dst[n]); err != nil {
+			stop = fmt.Errorf("%w: token %d %q: %v", ErrInvalidToken, n+1, s, err)
+			return false
+		}
+		n++
+		return n < len(dst)
+	})
+	if err == nil {
+		err = stop
+	}
+	if err == nil && n < len(dst) {
+		err = io.ErrUnexpectedEOF
+	}
+	return n, err
+}
+
+// Ints returns each non-empty token returned by ArgsErr, as with Fields,
+// converted to an int by strconv.Atoi.
+// Conversion stops at the first token that is not a valid integer, and the
+// returned error wraps ErrInvalidToken and identifies the token and its
+// position in the slice returned by ArgsErr. The returned slice contains all
+// integers converted before the error occurred.
+func (in *Input) Ints(args []string) ([]int, error) {
+	return parseFields(in, args, strconv.Atoi)
+}
+
+// Floats is like Ints, but converts each token to a float64 by
+// strconv.ParseFloat.
+func (in *Input) Floats(args []string) ([]float64, error) {
+	return parseFields(in, args, func(s string) (float64, error) {
+		return strconv.ParseFloat(s, 64)
+	})
+}
+
+// parseFields returns each non-empty token returned by in.ArgsErr converted by
+// parse, stopping at the first error.
+func parseFields[T any](in *Input, args []string, parse func(string) (T, error)) ([]T, error) {
+	a, err := in.ArgsErr(args)
+	v := make([]T, 0, len(a))
+	for i, s := range a {
+		if in.empty(s) {
+			continue
+		}
+		n, perr := parse(s)
+		if perr != nil {
+			return v, fmt.Errorf("%w: token %d %q: %v", ErrInvalidToken, i+1, s, perr)
+		}
+		v = append(v, n)
+	}
+	return v, err
+}
+
+// scanToken converts the entire token s and stores the result in dst.
+func scanToken(s string, dst interface{}) error {
+	if p, ok := dst.(*string); ok {
+		*p = s
+		return nil
+	}
+	r := strings.NewReader(s)
+	if _, err := fmt.Fscan(r, dst); err != nil {
+		return err
+	}
+	if rest := strings.TrimSpace(s[len(s)-r.Len():]); rest != "" {
+		return fmt.Errorf("unexpected %q", rest)
+	}
+	return nil
+}
+
+// Peek returns the first token that would be returned by Args, along with an
+// io.Reader over the remaining input that follows it.
+// See ArgsN for a description of rest.
+//
+// If there are no tokens, returns io.EOF.
+func (in *Input) Peek(args []string) (first string, rest io.Reader, err error) {
+	a, rest, err := in.ArgsN(args, 1)
+	if err != nil {
+		return "", nil, err
+	}
+	if len(a) == 0 {
+		return "", rest, io.EOF
+	}
+	return a[0], rest, nil
+}
+
+// ArgsN returns the first n tokens that would be returned by Args (or fewer,
+// if the input contains fewer than n tokens), along with an io.Reader over the
+// remaining input that follows those tokens.
+//
+// If args is non-empty, rest is a reader over the elements of args after the
+// last token returned, joined by ReadDelim.
+// Otherwise, rest is a reader over the content of Stream following the last
+// token and its delimiter. This includes any data buffered from Stream while
+// scanning tokens, followed by the unread remainder of Stream.
+// Options that convert Stream (e.g., Encoding) have already been applied to
+// the data returned by rest.
+//
+// MaxTokens is ignored. If n is not positive, no tokens are read.
+func (in *Input) ArgsN(args []string, n int) (tokens []string, rest io.Reader, err error) {
+	if n <= 0 {
+		if !in.isStream(args) {
+			return []string{}, in.literal(args), nil
+		}
+		return []string{}, in.stream(), nil
+	}
+	sub := *in
+	sub.MaxTokens = n
+	sub.unread = &remainder{}
+	tokens = make([]string, 0, min(n, max(len(args), 1)))
+	pos := -1
+	err = sub.tokensAt(args, func(i int, s string) bool {
+		tokens, pos = append(tokens, s), i
+		return true
+	})
+	if err != nil {
+		return tokens, nil, err
+	}
+	if !in.isStream(args) {
+		return tokens, in.literal(args[pos+1:]), nil
+	}
+	return tokens, sub.unread.reader(), nil
+}
+
+// Drain reads and discards all remaining data from Stream, and returns the
+// number of bytes discarded. This allows the writer at the other end of a pipe
+// to finish promptly, rather than blocking or receiving a broken pipe signal.
+// If Stream is already exhausted, Drain returns 0 and nil.
+//
+// Data already read from Stream into a buffer (e.g., the rest returned by
+// ArgsN) is not counted.
+func (in *Input) Drain() (int64, error) {
+	return io.Copy(io.Discard, in.input())
+}
+
+// NumberedToken is a token returned by ArgsNumbered, along with its position in
+// the input.
+type NumberedToken struct {
+	// The one-based position of the token in args or Stream. When reading from
+	// Stream, this is the line number if ArgsDelim is a newline.
+	Line int
+	// The token itself.
+	Text string
+}
+
+// ArgsNumbered is like Args, but returns each token along with its position in
+// the input.
+// Positions count every token delimited in the input, including empty tokens
+// and tokens discarded by options such as CommentPrefix or Unique, so that
+// they always agree with the line numbers reported by a text editor.
+func (in *Input) ArgsNumbered(args []string) []NumberedToken {
+	a := make([]NumberedToken, 0, len(args))
+	_ = in.tokensAt(args, func(i int, s string) bool {
+		a = append(a, NumberedToken{Line: i + 1, Text: s})
+		return true
+	})
+	return a
 }
 
 // Fields wraps Args, and removes all empty (zeroed) string elements in the
-// returned slice.
+// returned slice, or all elements for which IsEmpty returns true if it is
+// non-nil.
 func (in *Input) Fields(args []string) []string {
+	return in.Filter(args, func(s string) bool { return !in.empty(s) })
+}
+
+// empty reports whether s is an empty token removed by Fields.
+func (in *Input) empty(s string) bool {
+	if in.IsEmpty != nil {
+		return in.IsEmpty(s)
+	}
+	return s == ""
+}
+
+// IndexedField is a token returned by FieldsIndexed, along with its index in
+// the slice that would be returned by Args.
+type IndexedField struct {
+	// The zero-based index of the token among all tokens returned by Args,
+	// including the empty tokens removed by FieldsIndexed.
+	Index int
+	// The token itself.
+	Value string
+}
+
+// FieldsIndexed is like Fields, but returns each token along with its index in
+// the slice that would be returned by Args, so that tokens can be identified by
+// their position before empty tokens were removed.
+func (in *Input) FieldsIndexed(args []string) []IndexedField {
+	a := make([]IndexedField, 0, len(args))
+	for i, s := range in.Args(args) {
+		if !in.empty(s) {
+			a = append(a, IndexedField{Index: i, Value: s})
+		}
+	}
+	return a
+}
+
+// FieldsTrim is like Fields, but also removes all elements that contain only
+// white space, as defined by Unicode.
+// The elements that remain are returned unmodified; use TrimSpace to also
+// remove white space from around each token.
+func (in *Input) FieldsTrim(args []string) []string {
+	return in.Filter(args, func(s string) bool {
+		return !in.empty(s) && strings.TrimSpace(s) != ""
+	})
+}
+
+// Filter wraps Args, and returns only the tokens for which keep returns true,
+// in their original order. Each token is passed to keep after all processing
+// configured in Input (e.g., TrimSpace).
+// Fields and FieldsTrim are special cases of Filter.
+func (in *Input) Filter(args []string, keep func(string) bool) []string {
 	args = in.Args(args)
 	a := make([]string, 0, len(args))
 	for _, s := range args {
-		if s != "" {
+		if keep(s) {
 			a = append(a, s)
 		}
 	}
 	return a
 }
 
+// ArgsSet returns the set of distinct tokens returned by Fields, i.e.,
+// excluding empty tokens, which are rarely useful set members.
+// Use Unique instead to remove duplicate tokens while keeping their order.
+func (in *Input) ArgsSet(args []string) map[string]struct{} {
+	m := map[string]struct{}{}
+	_ = in.tokens(args, func(s string) bool {
+		if !in.empty(s) {
+			m[s] = struct{}{}
+		}
+		return true
+	})
+	return m
+}
+
+// Partition returns the tokens returned by Args, separated into flags and
+// positional arguments, each in their original order.
+// A token is a flag if it begins with "-" (e.g., "-x" or "--foo=bar"), except
+// "-" alone, which conventionally refers to Stream. The first token "--" ends
+// the flags: it is discarded, and all tokens following it are positional.
+//
+// Partition does not interpret the flags, so a flag's value given as a
+// separate token (e.g., "-o file") is returned as a positional argument.
+func (in *Input) Partition(args []string) (flags []string, positionals []string) {
+	flags, positionals = []string{}, []string{}
+	a := in.Args(args)
+	for i, s := range a {
+		if s == "--" {
+			return flags, append(positionals, a[i+1:]...)
+		}
+		if len(s) > 1 && s[0] == '-' {
+			flags = append(flags, s)
+		} else {
+			positionals = append(positionals, s)
+		}
+	}
+	return flags, positionals
+}
+
 // Reader returns an io.Reader over the string constructed by joining all
 // elements in the given non-empty slice args, separated by ReadDelim.
 // If the given args contains a single element, and that element refers to
@@ -114,53 +1481,1017 @@ func (in *Input) Fields(args []string) []string {
 // file is returned.
 // Otherwise, args is empty, returns Stream.
 func (in *Input) Reader(args []string) io.Reader {
+	r, err := in.ReaderErr(args)
+	if err != nil {
+		// The input could not be opened, read the string itself.
+		return in.wrap(in.literal(args))
+	}
+	return r
+}
+
+// Source identifies where Reader and Args obtain their input.
+type Source int
+
+// Constants defining each Source of input.
+const (
+	// SourceStream indicates input is read from Stream.
+	SourceStream Source = iota
+	// SourceArgs indicates input is read from the given arguments themselves.
+	SourceArgs
+	// SourceFile indicates input is read from one or more files named by the
+	// given arguments.
+	SourceFile
+	// SourceURL indicates input is read from the URL given as argument.
+	SourceURL
+)
+
+// String returns a short, lowercase description of s.
+func (s Source) String() string {
+	switch s {
+	case SourceStream:
+		return "stream"
+	case SourceArgs:
+		return "args"
+	case SourceFile:
+		return "file"
+	case SourceURL:
+		return "url"
+	}
+	return "Source(" + strconv.Itoa(int(s)) + ")"
+}
+
+// Source returns the Source from which Reader would read, given args, without
+// reading any input, and without opening any input except as follows: with
+// FDArg, a file descriptor is duplicated (and the duplicate closed) to verify
+// that it is open; and if OpenFunc is non-nil, it is called and the result
+// closed, since there is no other way to determine whether it can open the
+// argument.
+// Args never reads the content of files or URLs, so for Args, any result other
+// than SourceStream means the tokens are the given args themselves.
+//
+// A path that exists but cannot be opened (e.g., a directory) is still
+// reported as SourceFile, since ReaderErr reports an error for it rather than
+// reading it as a string.
+func (in *Input) Source(args []string) Source {
+	switch {
+	case in.isStream(args):
+		return SourceStream
+	case in.Literal:
+		return SourceArgs
+	case len(args) > 1:
+		if in.MultiFile && allFiles(args) {
+			return SourceFile
+		}
+		return SourceArgs
+	case in.AllowURL && isURL(args[0]):
+		return SourceURL
+	case in.FDArg && isOpenFD(args[0]):
+		return SourceFile
+	}
+	path := args[0]
+	if in.ExpandTilde {
+		path = expandTilde(path)
+	}
+	if in.OpenFunc != nil {
+		rc, err := in.OpenFunc(path)
+		if err == nil {
+			_ = rc.Close()
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return SourceFile
+		}
+		return SourceArgs
+	}
+	if _, err := os.Stat(path); err == nil {
+		return SourceFile
+	}
+	if in.ExpandGlob && strings.ContainsAny(path, "*?[") {
+		if paths, _ := filepath.Glob(path); slices.ContainsFunc(paths, isFile) {
+			return SourceFile
+		}
+	}
+	return SourceArgs
+}
+
+// ReaderOr returns the reader returned by Reader, or def if that reader has no
+// content (i.e., it reaches EOF before returning any data).
+//
+// To determine this, ReaderOr reads from the reader until at least one byte
+// is received, which may block if reading from Stream. That data is then
+// returned by the first Read on the returned reader.
+// Any file opened by Reader is closed if def is returned, or if the first Read
+// fails, in which case the returned reader fails with the same error.
+func (in *Input) ReaderOr(args []string, def io.Reader) io.Reader {
+	r, c, err := in.open(args)
+	if err != nil {
+		// The input could not be opened, read the string itself.
+		r, c = in.wrap(in.literal(args)), nil
+	}
+	var b [1]byte
+	for {
+		n, err := r.Read(b[:])
+		if n > 0 {
+			return io.MultiReader(bytes.NewReader(b[:n]), r)
+		}
+		if err == io.EOF {
+			if c != nil {
+				_ = c.Close()
+			}
+			return def
+		}
+		if err != nil {
+			if c != nil {
+				_ = c.Close()
+			}
+			return errReader{err}
+		}
+	}
+}
+
+// ReaderErr is like Reader, but if the single element of args refers to an
+// existing filesystem entry (as reported by os.Stat) that cannot be opened for
+// reading, such as a directory or a file without read permission, the error is
+// returned instead of falling back to reading the string itself.
+// For a directory, the error is an *fs.PathError wrapping ErrIsDirectory.
+// If the path does not exist, the string itself is read, as with Reader.
+func (in *Input) ReaderErr(args []string) (io.Reader, error) {
+	r, _, err := in.open(args)
+	return r, err
+}
+
+// ReaderFS is like ReaderErr, but a single argument is resolved as a file in
+// fsys (e.g., an embed.FS) instead of the operating system's file system.
+// The argument must be a valid path as defined by fs.ValidPath; otherwise, or
+// if it does not exist in fsys, the argument is read as a string.
+// All other arguments are handled as with ReaderErr, including Literal.
+//
+// The file opened in fsys, if any, is not closed. Files in an embed.FS or an
+// fstest.MapFS need not be closed; otherwise, use ReaderCloser with an
+// OpenFunc that opens files in fsys.
+func (in *Input) ReaderFS(fsys fs.FS, args []string) (io.Reader, error) {
+	sub := *in
+	sub.OpenFunc = func(name string) (io.ReadCloser, error) {
+		if !fs.ValidPath(name) {
+			return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+		}
+		return fsys.Open(name)
+	}
+	return sub.ReaderErr(args)
+}
+
+// ReaderSeeker is like ReaderErr, but returns an io.ReadSeeker, so that the
+// content can be read again after seeking back to the start.
+// If the content read by Reader is already seekable (e.g., a single file
+// argument, a string literal, or a seekable Stream), it is returned directly.
+// Note that offsets are then those of the underlying file or Stream, which may
+// have been partially read already. If args selects a file, the returned
+// *os.File was opened by ReaderSeeker and should be closed by the caller.
+// If args selects Stream, Stream itself may be returned (e.g., os.Stdin
+// redirected from a regular file), and it must not be closed on its behalf.
+// Use Source to distinguish the two.
+//
+// Otherwise (e.g., a pipe, or content converted by Decode or decompressed),
+// the entire content is read into memory, any file opened is closed, and a
+// bytes.Reader over the content is returned.
+func (in *Input) ReaderSeeker(args []string) (io.ReadSeeker, error) {
+	r, c, err := in.open(args)
+	if err != nil {
+		return nil, err
+	}
+	if rs, ok := r.(io.ReadSeeker); ok {
+		if _, err := rs.Seek(0, io.SeekCurrent); err == nil {
+			return rs, nil
+		}
+	}
+	b, err := io.ReadAll(r)
+	if c != nil {
+		if cerr := c.Close(); err == nil {
+			err = cerr
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return bytes.NewReader(b), nil
+}
+
+// ReaderCloser is like Reader, but returns an io.ReadCloser whose Close method
+// closes the file opened when args contains a single file path.
+// Stream and string literals are wrapped with io.NopCloser, so closing them has
+// no effect.
+//
+// As with ReaderErr, if the single element of args refers to a file that exists
+// but cannot be opened, the error is returned instead of falling back to
+// reading the string itself.
+func (in *Input) ReaderCloser(args []string) (io.ReadCloser, error) {
+	r, c, err := in.open(args)
+	if err != nil {
+		return nil, err
+	}
+	if c == nil {
+		return io.NopCloser(r), nil
+	}
+	return readCloser{Reader: r, Closer: c}, nil
+}
+
+// ReadAll returns the entire content of the reader returned by ReaderCloser,
+// and then closes it.
+// The returned error is the first error encountered while opening, reading,
+// or closing, if any.
+func (in *Input) ReadAll(args []string) ([]byte, error) {
+	rc, err := in.ReaderCloser(args)
+	if err != nil {
+		return nil, err
+	}
+	b, err := io.ReadAll(rc)
+	if cerr := rc.Close(); err == nil {
+		err = cerr
+	}
+	return b, err
+}
+
+// Joined is like ReadAll, but returns the content as a string: the elements of
+// args joined by ReadDelim, the content of the file referred to by a single
+// element of args, or the content of Stream, according to the same rules as
+// Reader. Any file opened is closed before returning.
+func (in *Input) Joined(args []string) (string, error) {
+	b, err := in.ReadAll(args)
+	return string(b), err
+}
+
+// Copy copies the entire content of the reader returned by ReaderCloser to w,
+// and then closes it.
+// Returns the number of bytes copied and the first error encountered while
+// opening, copying, or closing, if any.
+func (in *Input) Copy(args []string, w io.Writer) (int64, error) {
+	rc, err := in.ReaderCloser(args)
+	if err != nil {
+		return 0, err
+	}
+	n, err := io.Copy(w, rc)
+	if cerr := rc.Close(); err == nil {
+		err = cerr
+	}
+	return n, err
+}
+
+// WriteArgs writes tokens to w, separated by ArgsDelim, as the inverse of Args
+// reading tokens from Stream. If WriteFinalDelim is true, ArgsDelim is also
+// written after the final token.
+// No per-token processing (e.g., TrimSpace) or escaping is performed, so a
+// token containing ArgsDelim is read back by Args as more than one token.
+// Returns the number of bytes written and the first error encountered, if any.
+func (in *Input) WriteArgs(w io.Writer, tokens []string) (int, error) {
+	var b bytes.Buffer
+	for i, s := range tokens {
+		if i > 0 {
+			b.Write(in.ArgsDelim)
+		}
+		b.WriteString(s)
+	}
+	if in.WriteFinalDelim && len(tokens) > 0 {
+		b.Write(in.ArgsDelim)
+	}
+	return w.Write(b.Bytes())
+}
+
+// open returns a reader over the input selected by args, as documented by
+// Reader, along with an io.Closer for any resource opened on behalf of the
+// caller (or nil if there is nothing to close).
+// A non-nil error is returned if a file that exists could not be opened, or if
+// Stream is a terminal and ErrorOnTTY is true.
+func (in *Input) open(args []string) (io.Reader, io.Closer, error) {
+	r, c, err := in.source(args)
+	if err != nil {
+		return nil, nil, err
+	}
+	if in.Progress != nil {
+		r = &progressReader{r: r, total: size(r), report: in.Progress}
+	}
+	return in.wrap(r), c, nil
+}
+
+// size returns the number of bytes that can be read from r, or -1 if unknown.
+func size(r io.Reader) int64 {
+	switch v := r.(type) {
+	case *os.File:
+		if fi, err := v.Stat(); err == nil && fi.Mode().IsRegular() {
+			return fi.Size()
+		}
+	case *strings.Reader:
+		return v.Size()
+	}
+	return -1
+}
+
+// progressInterval is the minimum time between calls to Progress.
+const progressInterval = 100 * time.Millisecond
+
+// progressReader reports the number of bytes read from r to report.
+type progressReader struct {
+	r      io.Reader
+	n      int64
+	total  int64
+	last   time.Time
+	report func(bytesRead, total int64)
+}
+
+func (pr *progressReader) Read(p []byte) (int, error) {
+	n, err := pr.r.Read(p)
+	pr.n += int64(n)
+	if now := time.Now(); err != nil || now.Sub(pr.last) >= progressInterval {
+		pr.last = now
+		pr.report(pr.n, pr.total)
+	}
+	return n, err
+}
+
+// wrap returns r adjusted according to the options configured in Input that
+// apply to the content returned by Reader.
+func (in *Input) wrap(r io.Reader) io.Reader {
+	r = in.Decode.decoder(r)
+	if in.NormalizeNewlines {
+		r = &newlineReader{r: r}
+	}
+	if in.Replace != nil {
+		r = &replaceReader{r: r, rep: in.Replace}
+	}
+	return r
+}
+
+// source returns the unmodified reader selected by args for open.
+func (in *Input) source(args []string) (io.Reader, io.Closer, error) {
+	if in.isStream(args) {
+		args = nil
+	}
 	switch len(args) {
 	case 0:
 		// No arguments: read from Stream.
-		return in.Stream
+		if err := in.checkTTY(); err != nil {
+			return nil, nil, err
+		}
+		return in.tee(in.limit(in.input())), nil, nil
 	case 1:
+		if !in.Literal && in.AllowURL && isURL(args[0]) {
+			// One argument: if it is a URL, read the response body.
+			return fetch(args[0])
+		}
+		if !in.Literal && in.FDArg {
+			// One argument: if it is a file descriptor, read from it.
+			if f := openFD(args[0]); f != nil {
+				if in.AutoDecompress {
+					r, c := decompress(f)
+					return r, c, nil
+				}
+				return f, f, nil
+			}
+		}
 		if !in.Literal {
+			path := args[0]
+			if in.ExpandTilde {
+				path = expandTilde(path)
+			}
 			// One argument: if it is a file path, read from the file.
-			if r, err := os.Open(args[0]); nil == err {
-				return r
+			r, c, err := in.openArg(path)
+			if nil == err {
+				return r, c, nil
+			}
+			if in.OpenFunc != nil {
+				if !errors.Is(err, fs.ErrNotExist) {
+					// One argument: the file exists, but could not be opened.
+					return nil, nil, err
+				}
+			} else if _, serr := os.Stat(path); serr == nil {
+				// One argument: the file exists, but could not be opened.
+				return nil, nil, err
+			}
+			if in.ExpandGlob && in.OpenFunc == nil {
+				// One argument: if it is a glob pattern, read from each file.
+				if r, c, err := in.openGlob(path); err != nil || r != nil {
+					return r, c, err
+				}
 			}
 		}
 		// One argument: not a file path, read the string itself.
-		return strings.NewReader(args[0])
+		return in.literal(args), nil, nil
 	default:
+		if !in.Literal && in.MultiFile && allFiles(args) {
+			// More than one argument: if all are file paths, read from each
+			// file in order.
+			return in.openFiles(args)
+		}
 		// More than one argument: read from the string constructed by
 		// joining all arguments, delimited by ReadDelim.
-		return strings.NewReader(strings.Join(args, string(in.ReadDelim)))
+		return in.literal(args), nil, nil
 	}
 }
 
-func (in *Input) scanArgs(data []byte, atEOF bool) (int, []byte, error) {
+// isStream reports whether args designates Stream as the source of input,
+// i.e., args is empty, or DashStdin is true and args contains only "-".
+func (in *Input) isStream(args []string) bool {
+	return len(args) == 0 || (in.DashStdin && len(args) == 1 && args[0] == "-")
+}
+
+// expandTilde returns path with a leading "~" (either alone, or followed by a
+// path separator) replaced by the current user's home directory.
+// Returns path unmodified if it has no such prefix, or if the home directory
+// cannot be determined.
+func expandTilde(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~/") &&
+		!strings.HasPrefix(path, "~"+string(os.PathSeparator)) {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return home + path[1:]
+}
+
+// checkTTY returns ErrTerminal if ErrorOnTTY is true and Stream is a terminal.
+func (in *Input) checkTTY() error {
+	if f, ok := in.Stream.(*os.File); ok && in.ErrorOnTTY && isTerminal(f) {
+		return ErrTerminal
+	}
+	return nil
+}
+
+// literal returns a reader over the string constructed by joining all elements
+// of args, delimited by ReadDelim (or by calling JoinFunc), after expanding each
+// element if ExpandEnv is true.
+func (in *Input) literal(args []string) *strings.Reader {
+	if in.ExpandEnv {
+		exp := make([]string, len(args))
+		for i, s := range args {
+			exp[i] = os.ExpandEnv(s)
+		}
+		args = exp
+	}
+	if in.JoinFunc != nil && len(args) > 1 {
+		return strings.NewReader(in.JoinFunc(args))
+	}
+	return strings.NewReader(strings.Join(args, string(in.ReadDelim)))
+}
+
+// isURL reports whether s is an absolute HTTP or HTTPS URL.
+func isURL(s string) bool {
+	u, err := url.Parse(s)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// fetch returns the body of the response to an HTTP GET request for the given
+// URL, which must be closed by the caller.
+// A response with a non-2xx status code is returned as an error.
+func fetch(rawURL string) (io.Reader, io.Closer, error) {
+	resp, err := http.Get(rawURL)
+	if err != nil {
+		return nil, nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		_ = resp.Body.Close()
+		return nil, nil, fmt.Errorf("clin: GET %s: %s", rawURL, resp.Status)
+	}
+	return resp.Body, resp.Body, nil
+}
+
+// isOpenFD reports whether s is the decimal number of an open file descriptor
+// that source would read with FDArg, as determined by openFD.
+func isOpenFD(s string) bool {
+	f := openFD(s)
+	if f == nil {
+		return false
+	}
+	_ = f.Close()
+	return true
+}
+
+// openFD returns a file for a duplicate of the open file descriptor whose
+// decimal number is s, or nil if s is not a file descriptor accepted by FDArg
+// or is not open.
+// The descriptor itself is never closed, because it is owned by the caller,
+// and an *os.File wrapping it directly would close it when garbage collected.
+func openFD(s string) *os.File {
+	fd, err := strconv.ParseUint(s, 10, 16)
+	if err != nil {
+		return nil
+	}
+	nfd, err := dupFD(int(fd))
+	if err != nil {
+		return nil
+	}
+	f := os.NewFile(uintptr(nfd), "/dev/fd/"+s)
+	if f == nil {
+		return nil
+	}
+	if _, err := f.Stat(); err != nil {
+		_ = f.Close()
+		return nil
+	}
+	return f
+}
+
+// isFile reports whether path refers to an existing file that is not a
+// directory.
+func isFile(path string) bool {
+	fi, err := os.Stat(path)
+	return err == nil && !fi.IsDir()
+}
+
+// allFiles reports whether every element of paths satisfies isFile.
+func allFiles(paths []string) bool {
+	for _, p := range paths {
+		if !isFile(p) {
+			return false
+		}
+	}
+	return true
+}
+
+// openArg opens the file named by a single argument, using OpenFunc if it is
+// non-nil, and otherwise openFile.
+func (in *Input) openArg(path string) (io.Reader, io.Closer, error) {
+	if in.OpenFunc == nil {
+		return in.openFile(path)
+	}
+	rc, err := in.OpenFunc(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	if st, ok := rc.(interface{ Stat() (fs.FileInfo, error) }); ok {
+		if fi, err := st.Stat(); err == nil && fi.IsDir() {
+			_ = rc.Close()
+			return nil, nil, &fs.PathError{Op: "open", Path: path, Err: ErrIsDirectory}
+		}
+	}
+	if f, ok := rc.(*os.File); ok && in.AutoDecompress {
+		r, c := decompress(f)
+		return r, c, nil
+	}
+	return rc, rc, nil
+}
+
+// openFile opens the file at the given path for reading, decompressing its
+// content if AutoDecompress is true.
+// Directories cannot be opened for reading.
+func (in *Input) openFile(path string) (io.Reader, io.Closer, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	if fi, err := f.Stat(); err == nil && fi.IsDir() {
+		_ = f.Close()
+		return nil, nil, &fs.PathError{Op: "open", Path: path, Err: ErrIsDirectory}
+	}
+	if in.AutoDecompress {
+		r, c := decompress(f)
+		return r, c, nil
+	}
+	return f, f, nil
+}
+
+// openGlob returns a reader over the concatenated content of each file that
+// matches the given pattern, in the lexical order returned by filepath.Glob.
+// Directories are excluded.
+// Returns a nil io.Reader and error if pattern contains no glob metacharacters
+// or matches no files.
+func (in *Input) openGlob(pattern string) (io.Reader, io.Closer, error) {
+	if !strings.ContainsAny(pattern, "*?[") {
+		return nil, nil, nil
+	}
+	paths, err := filepath.Glob(pattern)
+	if err != nil {
+		// Malformed pattern: not a glob.
+		return nil, nil, nil
+	}
+	files := paths[:0]
+	for _, p := range paths {
+		if isFile(p) {
+			files = append(files, p)
+		}
+	}
+	if len(files) == 0 {
+		return nil, nil, nil
+	}
+	return in.openFiles(files)
+}
+
+// openFiles returns a reader over the concatenated content of each file in the
+// given paths, in order, along with an io.Closer that closes all of them.
+// If any file cannot be opened, all files already opened are closed, and the
+// error is returned.
+func (in *Input) openFiles(paths []string) (io.Reader, io.Closer, error) {
+	rs := make([]io.Reader, 0, len(paths))
+	cs := make(closers, 0, len(paths))
+	for _, p := range paths {
+		r, c, err := in.openFile(p)
+		if err != nil {
+			_ = cs.Close()
+			return nil, nil, err
+		}
+		rs = append(rs, r)
+		cs = append(cs, c)
+	}
+	return io.MultiReader(rs...), cs, nil
+}
+
+// errReader is an io.Reader that always returns err.
+type errReader struct {
+	err error
+}
+
+func (e errReader) Read([]byte) (int, error) { return 0, e.err }
+
+// tee returns r with all data read from it written to Tee, if Tee is non-nil.
+func (in *Input) tee(r io.Reader) io.Reader {
+	if in.Tee == nil {
+		return r
+	}
+	return io.TeeReader(r, in.Tee)
+}
+
+// limit returns r limited to MaxBytes bytes, if MaxBytes is positive.
+func (in *Input) limit(r io.Reader) io.Reader {
+	if in.MaxBytes <= 0 {
+		return r
+	}
+	return &limitReader{r: r, n: in.MaxBytes}
+}
+
+// retryDelay is the time waited before the first retry of a failed Read when
+// RetryReads is positive. It doubles with each consecutive retry, up to
+// maxRetryDelay.
+const (
+	retryDelay    = 10 * time.Millisecond
+	maxRetryDelay = time.Second
+)
+
+// retry returns r with failed reads retried RetryReads times, if RetryReads is
+// positive.
+func (in *Input) retry(r io.Reader) io.Reader {
+	if in.RetryReads <= 0 {
+		return r
+	}
+	return &retryReader{r: r, max: in.RetryReads}
+}
+
+// retryReader retries each failed Read of r up to max times, except io.EOF.
+type retryReader struct {
+	r   io.Reader
+	max int
+}
+
+func (rr *retryReader) Read(p []byte) (int, error) {
+	delay := retryDelay
+	for i := 0; ; i++ {
+		n, err := rr.r.Read(p)
+		switch {
+		case err == nil || err == io.EOF:
+			return n, err
+		case n > 0:
+			// Deliver the data now, and retry with the next Read.
+			return n, nil
+		case i == rr.max:
+			return 0, err
+		}
+		time.Sleep(delay)
+		delay = min(2*delay, maxRetryDelay)
+	}
+}
+
+// limitReader is like io.LimitedReader, but returns ErrInputTooLarge instead of
+// io.EOF if the underlying reader contains more than n bytes.
+type limitReader struct {
+	r io.Reader
+	n int64
+}
+
+func (l *limitReader) Read(p []byte) (int, error) {
+	if l.n <= 0 {
+		var b [1]byte
+		k, err := io.ReadFull(l.r, b[:])
+		if k > 0 {
+			return 0, ErrInputTooLarge
+		}
+		return 0, err
+	}
+	if int64(len(p)) > l.n {
+		p = p[:l.n]
+	}
+	k, err := l.r.Read(p)
+	l.n -= int64(k)
+	return k, err
+}
+
+// countReader counts the number of bytes read from r.
+type countReader struct {
+	r io.Reader
+	n int64
+}
+
+func (cr *countReader) Read(p []byte) (int, error) {
+	n, err := cr.r.Read(p)
+	cr.n += int64(n)
+	return n, err
+}
+
+// newlineReader converts each CR+LF and lone CR read from r to LF.
+type newlineReader struct {
+	r  io.Reader
+	cr bool // whether the last byte read from r was CR
+}
+
+func (nr *newlineReader) Read(p []byte) (int, error) {
+	for {
+		n, err := nr.r.Read(p)
+		// The output is never longer than the input, so convert in place.
+		k := 0
+		for _, b := range p[:n] {
+			switch {
+			case b == '\r':
+				p[k], nr.cr = '\n', true
+				k++
+			case b == '\n' && nr.cr:
+				nr.cr = false
+			default:
+				p[k], nr.cr = b, false
+				k++
+			}
+		}
+		if k > 0 || n == 0 || err != nil {
+			return k, err
+		}
+		// Only the LF of a CR+LF was read; read again instead of returning 0.
+	}
+}
+
+// replaceReader reads the entire content of r, and returns it replaced
+// according to rep.
+type replaceReader struct {
+	r   io.Reader
+	rep *strings.Replacer
+	out *strings.Reader // the replaced content, once r has been read
+}
+
+func (rr *replaceReader) Read(p []byte) (int, error) {
+	if rr.out == nil {
+		b, err := io.ReadAll(rr.r)
+		if err != nil {
+			return 0, err
+		}
+		rr.out = strings.NewReader(rr.rep.Replace(string(b)))
+	}
+	return rr.out.Read(p)
+}
+
+// readCloser combines an io.Reader with the io.Closer that releases it.
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// closers is an io.Closer that closes each of its elements in order.
+type closers []io.Closer
+
+// Close closes each element of c, and returns all errors encountered.
+func (c closers) Close() error {
+	var errs []error
+	for _, e := range c {
+		if err := e.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
 
-	n := len(in.ArgsDelim)
+func (in *Input) scanArgs(data []byte, atEOF bool) (int, []byte, error) {
 
-	// Split on each UTF-8 rune if ArgsDelim is empty.
-	if n == 0 {
+	// Split on each UTF-8 rune if no delimiter is configured.
+	if !in.hasDelim() {
 		return bufio.ScanRunes(data, atEOF)
 	}
-	for i := 0; i <= len(data)-n; i++ {
-		if string(in.ArgsDelim) == string(data[i:i+n]) {
-			// If ArgsDelim is a simple newline, also remove any trailing "\r"
-			// that exists, which transparently handles Windows/DOS input.
+	// Skip over consecutive delimiters within the same call, because the
+	// scanner stops at EOF if no token is returned.
+	if in.CollapseDelims {
+		if skip := in.leadingDelims(data, atEOF); skip > 0 {
+			if in.Debug != nil {
+				in.debugf("skipped %d bytes of leading delimiters", skip)
+			}
+			adv, tok, err := in.scanArgs(data[skip:], atEOF)
+			return skip + adv, tok, err
+		}
+	}
+	// If any escape characters are removed, the token is copied into tok,
+	// which holds the unescaped content of data[:last].
+	var tok []byte
+	last := 0
+	token := func(end int) []byte {
+		if tok == nil {
+			return data[:end]
+		}
+		return append(tok, data[last:end]...)
+	}
+	for i := 0; i < len(data); i++ {
+		if in.EscapeChar != 0 && data[i] == in.EscapeChar {
+			if i+1 == len(data) {
+				if !atEOF {
+					// Need more data to know what is escaped.
+					return 0, nil, nil
+				}
+				// A trailing escape character at EOF is kept as-is.
+				break
+			}
+			n, more := in.delimAt(data[i+1:], atEOF)
+			if more {
+				return 0, nil, nil
+			}
+			if n == 0 && data[i+1] == in.EscapeChar {
+				n = 1
+			}
+			if n > 0 {
+				// Remove the escape character, and keep what it escapes.
+				if tok == nil {
+					tok = make([]byte, 0, len(data))
+				}
+				tok, last = append(tok, data[last:i]...), i+1
+				i += n
+			}
+			continue
+		}
+		n, more := in.delimAt(data[i:], atEOF)
+		if more {
+			// A delimiter may begin at this position, but we need more data to
+			// know for certain.
+			return 0, nil, nil
+		}
+		if n > 0 {
+			// If the delimiter begins with a newline, also remove any trailing
+			// "\r" that exists, which transparently handles Windows/DOS input.
 			// Besides this one possible byte, all other trailing whitespace is
 			// preserved in each token.
 			j := i
-			if i > 0 && data[i-1] == '\r' && n == 1 && in.ArgsDelim[0] == '\n' {
+			if !in.KeepCR && i > last && data[i-1] == '\r' && data[i] == '\n' {
 				j--
 			}
-			return i + n, data[:j], nil
+			if in.Debug != nil {
+				in.debugf("delimiter %q at offset %d", data[i:i+n], i)
+				if j < i {
+					in.debugf("trimmed \"\\r\" at offset %d", j)
+				}
+				in.debugf("token %q", token(j))
+			}
+			return i + n, token(j), nil
 		}
 	}
 	if !atEOF {
 		return 0, nil, nil
 	}
 	// If the input is terminated with a delimiter, we reach here with a zero-
-	// length slice data. Discard this empty, final token.
+	// length slice data. Discard this empty, final token, unless KeepFinalEmpty.
 	// All other empty tokens (consecutive delimiters) are preserved.
-	in.skipToken = len(data) == 0
-	return 0, data, bufio.ErrFinalToken
+	if len(data) == 0 {
+		if !in.KeepFinalEmpty || in.CollapseDelims {
+			if in.Debug != nil {
+				in.debugf("skipped final empty token")
+			}
+			return 0, nil, nil
+		}
+		data = []byte{} // a nil token would not be delivered
+	}
+	if in.Debug != nil {
+		in.debugf("final token %q at EOF", token(len(data)))
+	}
+	return 0, token(len(data)), bufio.ErrFinalToken
+}
+
+// debugf writes a line formatted according to format and a to Debug.
+// Callers check that Debug is non-nil first, so that the arguments are not
+// evaluated otherwise.
+func (in *Input) debugf(format string, a ...interface{}) {
+	fmt.Fprintf(in.Debug, "clin: "+format+"\n", a...)
+}
+
+// remainder records the data read by a bufio.Scanner that has not yet been
+// consumed by its split function.
+type remainder struct {
+	r   io.Reader
+	buf []byte
+}
+
+// track returns an io.Reader and bufio.SplitFunc wrapping r and split, which
+// must be used together by a bufio.Scanner to record the unconsumed content
+// of r in the receiver.
+func (m *remainder) track(r io.Reader, split bufio.SplitFunc) (io.Reader, bufio.SplitFunc) {
+	m.r = r
+	return m, func(data []byte, atEOF bool) (int, []byte, error) {
+		adv, tok, err := split(data, atEOF)
+		switch {
+		case err == bufio.ErrFinalToken:
+			m.buf = m.buf[len(data):]
+		case adv > 0:
+			m.buf = m.buf[adv:]
+		}
+		return adv, tok, err
+	}
+}
+
+// Read reads from the tracked reader, recording all data read.
+func (m *remainder) Read(p []byte) (int, error) {
+	n, err := m.r.Read(p)
+	m.buf = append(m.buf, p[:n]...)
+	return n, err
+}
+
+// reader returns an io.Reader over the recorded data not yet consumed,
+// followed by the unread content of the tracked reader.
+func (m *remainder) reader() io.Reader {
+	if m.r == nil {
+		return bytes.NewReader(m.buf)
+	}
+	return io.MultiReader(bytes.NewReader(m.buf), m.r)
+}
+
+// utf8BOM is the UTF-8 encoding of the byte order mark (U+FEFF).
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// input returns Stream, or an empty reader if Stream is nil.
+func (in *Input) input() io.Reader {
+	if in.Stream == nil {
+		return strings.NewReader("")
+	}
+	return in.Stream
+}
+
+// stream returns the reader from which Args scans tokens, which is Stream
+// adjusted according to the options configured in Input.
+func (in *Input) stream() io.Reader {
+	r := in.Encoding.decoder(in.tee(in.limit(in.retry(in.input()))))
+	if in.StripBOM {
+		br := bufio.NewReader(r)
+		if b, _ := br.Peek(len(utf8BOM)); bytes.Equal(b, utf8BOM) {
+			_, _ = br.Discard(len(utf8BOM))
+		}
+		r = br
+	}
+	return r
+}
+
+// leadingDelims returns the length of the run of delimiters at the beginning of
+// data, including the "\r" preceding each delimiter that begins with "\n" if
+// KeepCR is false.
+func (in *Input) leadingDelims(data []byte, atEOF bool) int {
+	i := 0
+	for i < len(data) {
+		j := i
+		if !in.KeepCR && data[j] == '\r' {
+			j++
+		}
+		n, _ := in.delimAt(data[j:], atEOF)
+		if n == 0 || (j > i && data[j] != '\n') {
+			break
+		}
+		i = j + n
+	}
+	return i
+}
+
+// hasDelim reports whether ArgsDelim, any element of MultiDelim, or DelimRunes
+// is non-empty.
+func (in *Input) hasDelim() bool {
+	if len(in.ArgsDelim) > 0 || len(in.DelimRunes) > 0 {
+		return true
+	}
+	for _, d := range in.MultiDelim {
+		if len(d) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// delimAt returns the length of the longest delimiter, from ArgsDelim,
+// MultiDelim, and DelimRunes, found at the beginning of data.
+// Each rune in DelimRunes is matched by its UTF-8 encoding, which can never
+// begin with a continuation byte, so multi-byte runes in data are never split.
+// If data is a proper prefix of some delimiter and atEOF is false, returns
+// more = true to indicate that more data is required to decide.
+func (in *Input) delimAt(data []byte, atEOF bool) (n int, more bool) {
+	match := func(d []byte) {
+		if len(d) == 0 || len(d) <= n {
+			return
+		}
+		if bytes.HasPrefix(data, d) {
+			n = len(d)
+		} else if !atEOF && len(data) < len(d) && bytes.HasPrefix(d, data) {
+			more = true
+		}
+	}
+	match(in.ArgsDelim)
+	for _, d := range in.MultiDelim {
+		match(d)
+	}
+	var enc [utf8.UTFMax]byte
+	for _, r := range in.DelimRunes {
+		match(enc[:utf8.EncodeRune(enc[:], r)])
+	}
+	return n, more
 }
@@ -1,10 +1,64 @@
 package clin
 
 import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"runtime"
+	"strconv"
 	"strings"
+	"sync"
+	"testing"
+	"testing/fstest"
+	"testing/iotest"
+	"time"
 )
 
+// failReader returns the content of r, followed by err instead of io.EOF.
+type failReader struct {
+	r   io.Reader
+	err error
+}
+
+func (f *failReader) Read(p []byte) (int, error) {
+	n, err := f.r.Read(p)
+	if err == io.EOF {
+		err = f.err
+	}
+	return n, err
+}
+
+// closerFunc is an io.Closer that calls itself.
+type closerFunc func() error
+
+func (f closerFunc) Close() error { return f() }
+
+// writeTemp creates a file named name in a temporary directory with the given
+// content, and returns its path.
+func writeTemp(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// emptyReader is an io.Reader that is always at EOF, and which is safe for
+// concurrent use.
+type emptyReader struct{}
+
+func (emptyReader) Read([]byte) (int, error) { return 0, io.EOF }
 
 func ExampleArgs() {
 
@@ -43,3 +97,2809 @@ of
 	// [	input	]
 	// [  tokens]
 }
+
+func ExampleInput_ArgsSeq() {
+
+	in := Default()
+	in.Stream = strings.NewReader("one\ntwo\n\nthree\n")
+
+	for s := range in.ArgsSeq([]string{}) {
+		fmt.Println("[" + s + "]")
+	}
+
+	// Output:
+	// [one]
+	// [two]
+	// []
+	// [three]
+}
+
+func TestInputArgsErr(t *testing.T) {
+
+	errBroken := errors.New("broken pipe")
+
+	in := Default()
+	in.Stream = &failReader{r: strings.NewReader("a\nb\nc"), err: errBroken}
+
+	a, err := in.ArgsErr([]string{})
+	if !errors.Is(err, errBroken) {
+		t.Fatalf("ArgsErr() error = %v, want %v", err, errBroken)
+	}
+	// Data buffered before the error is still delivered as a final token.
+	if want := []string{"a", "b", "c"}; !reflect.DeepEqual(a, want) {
+		t.Errorf("ArgsErr() = %q, want %q", a, want)
+	}
+
+	in.Stream = strings.NewReader("a\nb\nc")
+	a, err = in.ArgsErr([]string{})
+	if err != nil {
+		t.Fatalf("ArgsErr() error = %v, want nil", err)
+	}
+	if want := []string{"a", "b", "c"}; !reflect.DeepEqual(a, want) {
+		t.Errorf("ArgsErr() = %q, want %q", a, want)
+	}
+}
+
+func TestInputReaderCloser(t *testing.T) {
+
+	path := writeTemp(t, "input.txt", "file content")
+
+	in := Default()
+	rc, err := in.ReaderCloser([]string{path})
+	if err != nil {
+		t.Fatalf("ReaderCloser() error = %v", err)
+	}
+	b, err := io.ReadAll(rc)
+	if err != nil {
+		t.Fatalf("ReadAll() error = %v", err)
+	}
+	if got, want := string(b), "file content"; got != want {
+		t.Errorf("ReadAll() = %q, want %q", got, want)
+	}
+	if err := rc.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	// The underlying *os.File must now be closed.
+	if _, err := rc.Read(make([]byte, 1)); !errors.Is(err, os.ErrClosed) {
+		t.Errorf("Read() after Close() error = %v, want %v", err, os.ErrClosed)
+	}
+
+	// Closing a literal or Stream is a no-op.
+	in.Stream = strings.NewReader("stream")
+	for _, args := range [][]string{{}, {"literal"}, {"a", "b"}} {
+		rc, err := in.ReaderCloser(args)
+		if err != nil {
+			t.Fatalf("ReaderCloser(%q) error = %v", args, err)
+		}
+		if err := rc.Close(); err != nil {
+			t.Errorf("ReaderCloser(%q).Close() error = %v", args, err)
+		}
+	}
+}
+
+func TestInputArgsContext(t *testing.T) {
+
+	// The pipe never reaches EOF until the test completes, so reads from it
+	// block indefinitely after the first token.
+	pr, pw := io.Pipe()
+	defer pw.Close()
+	go pw.Write([]byte("first\nsecond"))
+
+	in := Default()
+	in.Stream = pr
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	a, err := in.ArgsContext(ctx, []string{})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("ArgsContext() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+	if want := []string{"first"}; !reflect.DeepEqual(a, want) {
+		t.Errorf("ArgsContext() = %q, want %q", a, want)
+	}
+
+	in.Stream = strings.NewReader("a\nb\n")
+	a, err = in.ArgsContext(context.Background(), []string{})
+	if err != nil {
+		t.Fatalf("ArgsContext() error = %v, want nil", err)
+	}
+	if want := []string{"a", "b"}; !reflect.DeepEqual(a, want) {
+		t.Errorf("ArgsContext() = %q, want %q", a, want)
+	}
+}
+
+func TestInputTrimSpace(t *testing.T) {
+
+	const stdin = "\ttab\t\ncr\r\r\n \t\r mixed \v\f\n \t \nplain\n"
+
+	for _, tt := range []struct {
+		trim   bool
+		args   []string
+		fields []string
+	}{
+		{
+			trim:   false,
+			args:   []string{"\ttab\t", "cr\r", " \t\r mixed \v\f", " \t ", "plain"},
+			fields: []string{"\ttab\t", "cr\r", " \t\r mixed \v\f", " \t ", "plain"},
+		},
+		{
+			trim:   true,
+			args:   []string{"tab", "cr", "mixed", "", "plain"},
+			fields: []string{"tab", "cr", "mixed", "plain"},
+		},
+	} {
+		in := Default()
+		in.TrimSpace = tt.trim
+
+		in.Stream = strings.NewReader(stdin)
+		if got := in.Args([]string{}); !reflect.DeepEqual(got, tt.args) {
+			t.Errorf("TrimSpace=%t: Args() = %q, want %q", tt.trim, got, tt.args)
+		}
+		in.Stream = strings.NewReader(stdin)
+		if got := in.Fields([]string{}); !reflect.DeepEqual(got, tt.fields) {
+			t.Errorf("TrimSpace=%t: Fields() = %q, want %q", tt.trim, got, tt.fields)
+		}
+	}
+}
+
+func TestInputCommentPrefix(t *testing.T) {
+
+	for _, tt := range []struct {
+		prefix string
+		delim  string
+		stdin  string
+		want   []string
+	}{
+		{
+			prefix: "#",
+			delim:  "\n",
+			stdin:  "# header\nvalue\n  # indented comment\n",
+			want:   []string{"value"},
+		},
+		{
+			prefix: "#",
+			delim:  ",",
+			stdin:  "#a,b,\t#c,d # not a comment",
+			want:   []string{"b", "d # not a comment"},
+		},
+		{
+			prefix: "//",
+			delim:  "\n",
+			stdin:  "a/b\n// c\n/d",
+			want:   []string{"a/b", "/d"},
+		},
+		{
+			prefix: "",
+			delim:  "\n",
+			stdin:  "# header\nvalue\n",
+			want:   []string{"# header", "value"},
+		},
+	} {
+		in := Default()
+		in.CommentPrefix = []byte(tt.prefix)
+		in.ArgsDelim = []byte(tt.delim)
+		in.Stream = strings.NewReader(tt.stdin)
+		if got := in.Args([]string{}); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("CommentPrefix=%q: Args(%q) = %q, want %q",
+				tt.prefix, tt.stdin, got, tt.want)
+		}
+	}
+}
+
+func TestInputMaxTokens(t *testing.T) {
+
+	for _, tt := range []struct {
+		max   int
+		stdin string
+		want  []string
+	}{
+		{max: 0, stdin: "a\nb\nc\n", want: []string{"a", "b", "c"}},
+		{max: 2, stdin: "a\nb\nc\n", want: []string{"a", "b"}},
+		{max: 3, stdin: "a\nb\nc\n", want: []string{"a", "b", "c"}},
+		{max: 3, stdin: "a\nb\nc", want: []string{"a", "b", "c"}},
+		{max: 4, stdin: "a\nb\nc\n", want: []string{"a", "b", "c"}},
+		{max: 4, stdin: "a\nb\nc\n\n", want: []string{"a", "b", "c", ""}},
+	} {
+		in := Default()
+		in.MaxTokens = tt.max
+		in.Stream = strings.NewReader(tt.stdin)
+		if got := in.Args([]string{}); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("MaxTokens=%d: Args(%q) = %q, want %q",
+				tt.max, tt.stdin, got, tt.want)
+		}
+	}
+
+	in := Default()
+	in.MaxTokens = 2
+	got, want := in.Args([]string{"x", "y", "z"}), []string{"x", "y"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("MaxTokens=2: Args() = %q, want %q", got, want)
+	}
+}
+
+func TestInputMaxTokenBytes(t *testing.T) {
+
+	long := strings.Repeat("x", 200*1024)
+	stdin := "short\n" + long + "\n"
+
+	in := Default()
+	in.Stream = strings.NewReader(stdin)
+	a, err := in.ArgsErr([]string{})
+	if !errors.Is(err, bufio.ErrTooLong) {
+		t.Fatalf("ArgsErr() error = %v, want %v", err, bufio.ErrTooLong)
+	}
+	if want := []string{"short"}; !reflect.DeepEqual(a, want) {
+		t.Errorf("ArgsErr() = %q, want %q", a, want)
+	}
+
+	in.MaxTokenBytes = 256 * 1024
+	in.Stream = strings.NewReader(stdin)
+	a, err = in.ArgsErr([]string{})
+	if err != nil {
+		t.Fatalf("MaxTokenBytes=%d: ArgsErr() error = %v", in.MaxTokenBytes, err)
+	}
+	if len(a) != 2 || a[0] != "short" || a[1] != long {
+		t.Errorf("MaxTokenBytes=%d: ArgsErr() returned %d tokens, want 2",
+			in.MaxTokenBytes, len(a))
+	}
+}
+
+func TestInputMultiDelim(t *testing.T) {
+
+	for _, tt := range []struct {
+		delim string
+		multi []string
+		stdin string
+		want  []string
+	}{
+		{
+			delim: "\n",
+			multi: []string{","},
+			stdin: "a,b\nc\r\n,d,\n",
+			want:  []string{"a", "b", "c", "", "d", ""},
+		},
+		{
+			delim: "",
+			multi: []string{",", "\n"},
+			stdin: "a,b\r\nc",
+			want:  []string{"a", "b", "c"},
+		},
+		{
+			delim: ";",
+			multi: []string{";;", "\n"},
+			stdin: "a;;b;c\nd",
+			want:  []string{"a", "b", "c", "d"},
+		},
+	} {
+		in := Default()
+		in.ArgsDelim = []byte(tt.delim)
+		for _, d := range tt.multi {
+			in.MultiDelim = append(in.MultiDelim, []byte(d))
+		}
+		// Deliver one byte at a time to exercise delimiters split across reads.
+		in.Stream = iotest.OneByteReader(strings.NewReader(tt.stdin))
+		if got := in.Args([]string{}); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("MultiDelim=%q: Args(%q) = %q, want %q",
+				tt.multi, tt.stdin, got, tt.want)
+		}
+	}
+}
+
+func TestInputDelimRunes(t *testing.T) {
+
+	for _, tt := range []struct {
+		delim string
+		runes []rune
+		stdin string
+		want  []string
+	}{
+		{
+			delim: "",
+			runes: []rune{' ', '\t', '\u00a0', '\u3000'},
+			stdin: "a b\tc\u00a0d\u3000e\u3000\u3000f",
+			want:  []string{"a", "b", "c", "d", "e", "", "f"},
+		},
+		{
+			// U+0080 is encoded as C2 80, and must not match the continuation
+			// byte in U+0100 (C4 80), nor the leading byte in U+00A2 (C2 A2).
+			delim: "",
+			runes: []rune{'\u0080'},
+			stdin: "\u0100\u0080\u00a2\u0080x",
+			want:  []string{"\u0100", "\u00a2", "x"},
+		},
+		{
+			delim: "\n",
+			runes: []rune{','},
+			stdin: "a,b\r\nc\n",
+			want:  []string{"a", "b", "c"},
+		},
+	} {
+		in := Default()
+		in.ArgsDelim = []byte(tt.delim)
+		in.DelimRunes = tt.runes
+		in.Stream = iotest.OneByteReader(strings.NewReader(tt.stdin))
+		if got := in.Args([]string{}); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("DelimRunes=%q: Args(%q) = %q, want %q",
+				tt.runes, tt.stdin, got, tt.want)
+		}
+	}
+}
+
+func TestDefaultNUL(t *testing.T) {
+
+	for _, tt := range []struct {
+		stdin string
+		want  []string
+	}{
+		{stdin: "a\x00b\x00c\x00", want: []string{"a", "b", "c"}},
+		{stdin: "a\r\x00b\nc\r\n\x00", want: []string{"a\r", "b\nc\r\n"}},
+	} {
+		in := DefaultNUL()
+		in.Stream = strings.NewReader(tt.stdin)
+		if got := in.Args([]string{}); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Args(%q) = %q, want %q", tt.stdin, got, tt.want)
+		}
+	}
+}
+
+func TestMerge(t *testing.T) {
+
+	first, second := Default(), DefaultNUL()
+	first.TrimSpace = true
+	first.Stream = strings.NewReader("a\n b \n")
+	second.Stream = strings.NewReader("c\nd\n")
+	merged := Merge(first, second)
+	want := []string{"a", "b", "c", "d"}
+	if got := merged.Args([]string{}); !reflect.DeepEqual(got, want) {
+		t.Errorf("Merge().Args() = %q, want %q", got, want)
+	}
+
+	first.Stream = strings.NewReader("a\nb")
+	second = Default()
+	second.Stream = strings.NewReader("c\n")
+	want = []string{"a", "bc"}
+	merged = Merge(first, Input{}, second)
+	if got := merged.Args([]string{}); !reflect.DeepEqual(got, want) {
+		t.Errorf("Merge().Args() = %q, want %q", got, want)
+	}
+
+	if got := Merge(); !reflect.DeepEqual(got.ArgsDelim, Default().ArgsDelim) ||
+		got.Stream != Default().Stream {
+		t.Errorf("Merge() = %+v, want Default()", got)
+	}
+}
+
+func TestInputStripBOM(t *testing.T) {
+
+	const stdin = "\xEF\xBB\xBFfirst\n\xEF\xBB\xBFsecond\n"
+
+	for _, tt := range []struct {
+		strip bool
+		want  []string
+	}{
+		{strip: false, want: []string{"\xEF\xBB\xBFfirst", "\xEF\xBB\xBFsecond"}},
+		{strip: true, want: []string{"first", "\xEF\xBB\xBFsecond"}},
+	} {
+		in := Default()
+		in.StripBOM = tt.strip
+		in.Stream = strings.NewReader(stdin)
+		if got := in.Args([]string{}); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("StripBOM=%t: Args() = %q, want %q", tt.strip, got, tt.want)
+		}
+	}
+
+	// A stream shorter than the BOM is unaffected.
+	in := Default()
+	in.StripBOM = true
+	in.Stream = strings.NewReader("\xEF")
+	if got, want := in.Args([]string{}), []string{"\xEF"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("StripBOM=true: Args() = %q, want %q", got, want)
+	}
+}
+
+func TestInputExpandGlob(t *testing.T) {
+
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"b.txt": "bravo\n",
+		"a.txt": "alpha\n",
+		"c.log": "charlie\n",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Mkdir(filepath.Join(dir, "d.txt"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		arg    string
+		expand bool
+		want   string // empty to expect the pattern itself
+	}{
+		{arg: "*.txt", expand: true, want: "alpha\nbravo\n"},
+		{arg: "?.*", expand: true, want: "alpha\nbravo\ncharlie\n"},
+		{arg: "[c].log", expand: true, want: "charlie\n"},
+		{arg: "*.txt", expand: false},
+		{arg: "*.md", expand: true},
+	} {
+		arg := filepath.Join(dir, tt.arg)
+		want := tt.want
+		if want == "" {
+			want = arg
+		}
+
+		in := Default()
+		in.ExpandGlob = tt.expand
+		rc, err := in.ReaderCloser([]string{arg})
+		if err != nil {
+			t.Fatalf("ReaderCloser(%q) error = %v", tt.arg, err)
+		}
+		b, err := io.ReadAll(rc)
+		if err != nil {
+			t.Fatalf("ReadAll() error = %v", err)
+		}
+		if got := string(b); got != want {
+			t.Errorf("ExpandGlob=%t: Reader(%q) = %q, want %q",
+				tt.expand, tt.arg, got, want)
+		}
+		if err := rc.Close(); err != nil {
+			t.Errorf("Close() error = %v", err)
+		}
+	}
+}
+
+func TestInputMultiFile(t *testing.T) {
+
+	a := writeTemp(t, "a.txt", "alpha\n")
+	b := writeTemp(t, "b.txt", "bravo\n")
+	missing := filepath.Join(t.TempDir(), "missing.txt")
+
+	for _, tt := range []struct {
+		name  string
+		args  []string
+		multi bool
+		want  string
+	}{
+		{"all-files", []string{b, a}, true, "bravo\nalpha\n"},
+		{"all-files-off", []string{b, a}, false, b + " " + a},
+		{"no-files", []string{"x", "y"}, true, "x y"},
+		{"mixed", []string{a, missing}, true, a + " " + missing},
+		{"mixed-literal", []string{a, "y", b}, true, a + " y " + b},
+	} {
+		in := Default()
+		in.MultiFile = tt.multi
+		rc, err := in.ReaderCloser(tt.args)
+		if err != nil {
+			t.Fatalf("%s: ReaderCloser() error = %v", tt.name, err)
+		}
+		got, err := io.ReadAll(rc)
+		if err != nil {
+			t.Fatalf("%s: ReadAll() error = %v", tt.name, err)
+		}
+		if string(got) != tt.want {
+			t.Errorf("%s: Reader() = %q, want %q", tt.name, got, tt.want)
+		}
+		if err := rc.Close(); err != nil {
+			t.Errorf("%s: Close() error = %v", tt.name, err)
+		}
+	}
+}
+
+func TestIsTerminal(t *testing.T) {
+
+	pr, pw, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pr.Close()
+	pw.Close()
+
+	f, err := os.Open(writeTemp(t, "regular.txt", "a\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	for _, tt := range []struct {
+		name string
+		file *os.File
+		want bool
+	}{
+		{"pipe", pr, false},
+		{"regular", f, false},
+		{"nil", nil, false},
+	} {
+		if got := IsTerminal(tt.file); got != tt.want {
+			t.Errorf("IsTerminal(%s) = %t, want %t", tt.name, got, tt.want)
+		}
+
+		if tt.file == nil {
+			continue
+		}
+		in := Default()
+		in.ErrorOnTTY = true
+		in.Stream = tt.file
+		if _, err := in.ArgsErr([]string{}); err != nil {
+			t.Errorf("%s: ArgsErr() error = %v, want nil", tt.name, err)
+		}
+	}
+}
+
+func TestInputUnique(t *testing.T) {
+
+	for _, tt := range []struct {
+		trim  bool
+		max   int
+		stdin string
+		want  []string
+	}{
+		{stdin: "a\nb\na\nc\nb\n", want: []string{"a", "b", "c"}},
+		{stdin: "a\n\nb\n\n", want: []string{"a", "", "b"}},
+		{stdin: "a\n a\na \n", want: []string{"a", " a", "a "}},
+		{trim: true, stdin: "a\n a\na \nb", want: []string{"a", "b"}},
+		{max: 2, stdin: "a\na\na\nb\nc", want: []string{"a", "b"}},
+	} {
+		in := Default()
+		in.Unique = true
+		in.TrimSpace = tt.trim
+		in.MaxTokens = tt.max
+		in.Stream = strings.NewReader(tt.stdin)
+		if got := in.Args([]string{}); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Args(%q) = %q, want %q", tt.stdin, got, tt.want)
+		}
+	}
+
+	in := Default()
+	in.Unique = true
+	got, want := in.Fields([]string{"a", "b", "a", "c", "b"}), []string{"a", "b", "c"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Fields() = %q, want %q", got, want)
+	}
+}
+
+func TestInputRejectDuplicates(t *testing.T) {
+
+	for _, tt := range []struct {
+		unique bool
+		stdin  string
+		want   []string
+		dup    string
+	}{
+		{stdin: "a\nb\nc\n", want: []string{"a", "b", "c"}},
+		{stdin: "a\nb\na\nb\n", want: []string{"a", "b"}, dup: `token 3 "a"`},
+		{unique: true, stdin: "a\nb\nb\n", want: []string{"a", "b"}, dup: `token 3 "b"`},
+	} {
+		in := Default()
+		in.RejectDuplicates = true
+		in.Unique = tt.unique
+		in.Stream = strings.NewReader(tt.stdin)
+		got, err := in.ArgsErr([]string{})
+		if tt.dup == "" && err != nil {
+			t.Errorf("ArgsErr(%q) error = %v, want nil", tt.stdin, err)
+		}
+		if tt.dup != "" && (!errors.Is(err, ErrDuplicateToken) || !strings.Contains(err.Error(), tt.dup)) {
+			t.Errorf("ArgsErr(%q) error = %v, want %v naming %s",
+				tt.stdin, err, ErrDuplicateToken, tt.dup)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ArgsErr(%q) = %q, want %q", tt.stdin, got, tt.want)
+		}
+	}
+}
+
+func TestInputTransform(t *testing.T) {
+
+	const stdin = "alpha\n-beta\ngamma\n-\n"
+
+	in := Default()
+	in.Transform = strings.ToUpper
+	in.Stream = strings.NewReader(stdin)
+	got, want := in.Args([]string{}), []string{"ALPHA", "-BETA", "GAMMA", "-"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Args() = %q, want %q", got, want)
+	}
+
+	// Tokens mapped to the empty string are removed by Fields.
+	in.Transform = func(s string) string {
+		if strings.HasPrefix(s, "-") {
+			return ""
+		}
+		return s
+	}
+	in.Stream = strings.NewReader(stdin)
+	got, want = in.Args([]string{}), []string{"alpha", "", "gamma", ""}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Args() = %q, want %q", got, want)
+	}
+	in.Stream = strings.NewReader(stdin)
+	got, want = in.Fields([]string{}), []string{"alpha", "gamma"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Fields() = %q, want %q", got, want)
+	}
+}
+
+func TestInputArgsNumbered(t *testing.T) {
+
+	in := Default()
+	in.CommentPrefix = []byte("#")
+	in.Stream = strings.NewReader("first\n\n# comment\nfourth\n\n\nseventh\n")
+	got := in.ArgsNumbered([]string{})
+	want := []NumberedToken{
+		{Line: 1, Text: "first"},
+		{Line: 2, Text: ""},
+		{Line: 4, Text: "fourth"},
+		{Line: 5, Text: ""},
+		{Line: 6, Text: ""},
+		{Line: 7, Text: "seventh"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ArgsNumbered() = %v, want %v", got, want)
+	}
+
+	got = in.ArgsNumbered([]string{"a", "", "c"})
+	want = []NumberedToken{{1, "a"}, {2, ""}, {3, "c"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ArgsNumbered() = %v, want %v", got, want)
+	}
+}
+
+func TestInputKeepFinalEmpty(t *testing.T) {
+
+	for _, tt := range []struct {
+		keep   bool
+		stdin  string
+		args   []string
+		fields []string
+	}{
+		{false, "a\nb\n", []string{"a", "b"}, []string{"a", "b"}},
+		{true, "a\nb\n", []string{"a", "b", ""}, []string{"a", "b"}},
+		{false, "a\nb", []string{"a", "b"}, []string{"a", "b"}},
+		{true, "a\nb", []string{"a", "b"}, []string{"a", "b"}},
+		{false, "", []string{}, []string{}},
+		{true, "", []string{""}, []string{}},
+	} {
+		in := Default()
+		in.KeepFinalEmpty = tt.keep
+		in.Stream = strings.NewReader(tt.stdin)
+		if got := in.Args([]string{}); !reflect.DeepEqual(got, tt.args) {
+			t.Errorf("KeepFinalEmpty=%t: Args(%q) = %q, want %q",
+				tt.keep, tt.stdin, got, tt.args)
+		}
+		if got := strings.Split(tt.stdin, "\n"); tt.keep && !reflect.DeepEqual(got, tt.args) {
+			t.Errorf("KeepFinalEmpty=%t: strings.Split(%q) = %q, want %q",
+				tt.keep, tt.stdin, got, tt.args)
+		}
+		in.Stream = strings.NewReader(tt.stdin)
+		if got := in.Fields([]string{}); !reflect.DeepEqual(got, tt.fields) {
+			t.Errorf("KeepFinalEmpty=%t: Fields(%q) = %q, want %q",
+				tt.keep, tt.stdin, got, tt.fields)
+		}
+	}
+}
+
+func TestInputKeepCR(t *testing.T) {
+
+	for _, tt := range []struct {
+		keep  bool
+		delim string
+		stdin string
+		want  []string
+	}{
+		{false, "\n", "a\r\nb\r\r\nc\r", []string{"a", "b\r", "c\r"}},
+		{true, "\n", "a\r\nb\r\r\nc\r", []string{"a\r", "b\r\r", "c\r"}},
+		{false, "\n\n", "a\r\n\nb\n\n", []string{"a", "b"}},
+		{true, "\n\n", "a\r\n\nb\n\n", []string{"a\r", "b"}},
+		{false, "\r\n", "a\r\nb\nc\r\n", []string{"a", "b\nc"}},
+		{false, ",", "a\r,b", []string{"a\r", "b"}},
+	} {
+		in := Default()
+		in.KeepCR = tt.keep
+		in.ArgsDelim = []byte(tt.delim)
+		in.Stream = strings.NewReader(tt.stdin)
+		if got := in.Args([]string{}); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("KeepCR=%t, ArgsDelim=%q: Args(%q) = %q, want %q",
+				tt.keep, tt.delim, tt.stdin, got, tt.want)
+		}
+	}
+
+	// The zero value of Input strips CR, as does Default.
+	in := Input{Stream: strings.NewReader("a\r\nb\r\n"), ArgsDelim: []byte("\n")}
+	if got, want := in.Args([]string{}), []string{"a", "b"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Input{}.Args() = %q, want %q", got, want)
+	}
+}
+
+func TestInputReadAll(t *testing.T) {
+
+	path := writeTemp(t, "input.txt", "file\ncontent\n")
+
+	for _, tt := range []struct {
+		name string
+		args []string
+		want string
+	}{
+		{"file", []string{path}, "file\ncontent\n"},
+		{"literal", []string{"literal"}, "literal"},
+		{"joined", []string{"a", "b"}, "a b"},
+		{"stdin", []string{}, "stdin\n"},
+	} {
+		in := Default()
+		in.Stream = strings.NewReader("stdin\n")
+		b, err := in.ReadAll(tt.args)
+		if err != nil {
+			t.Fatalf("%s: ReadAll() error = %v", tt.name, err)
+		}
+		if got := string(b); got != tt.want {
+			t.Errorf("%s: ReadAll() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestInputLines(t *testing.T) {
+
+	path := writeTemp(t, "input.txt", "one\r\ntwo\n\nthree\n")
+
+	for _, tt := range []struct {
+		name string
+		args []string
+		want []string
+	}{
+		{"file", []string{path}, []string{"one", "two", "", "three"}},
+		{"literal", []string{"a\nb\nc"}, []string{"a", "b", "c"}},
+		{"joined", []string{"a\nb", "c"}, []string{"a", "b c"}},
+		{"stdin", []string{}, []string{"x", "y"}},
+	} {
+		in := Default()
+		in.Stream = strings.NewReader("x\ny\n")
+		got, err := in.Lines(tt.args)
+		if err != nil {
+			t.Fatalf("%s: Lines() error = %v", tt.name, err)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: Lines() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestInputSplitFirst(t *testing.T) {
+
+	in := Default()
+	in.Stream = strings.NewReader("a: b c\nnone\nk:v:w\n\n")
+	want := [][2]string{{"a", " b c"}, {"none", ""}, {"k", "v:w"}, {"", ""}}
+	if got := in.SplitFirst([]string{}, ':'); !reflect.DeepEqual(got, want) {
+		t.Errorf("SplitFirst() = %q, want %q", got, want)
+	}
+}
+
+func TestInputPairs(t *testing.T) {
+
+	const stdin = "KEY=val\nEMPTY=\n\nURL=a=b\n KEY = last \nFLAG\n"
+
+	for _, tt := range []struct {
+		trim    bool
+		require bool
+		want    map[string]string
+		err     error
+	}{
+		{
+			want: map[string]string{
+				"KEY": "val", "EMPTY": "", "URL": "a=b", " KEY ": " last ", "FLAG": "",
+			},
+		},
+		{
+			trim: true,
+			want: map[string]string{"KEY": "last", "EMPTY": "", "URL": "a=b", "FLAG": ""},
+		},
+		{require: true, err: ErrMissingEquals},
+	} {
+		in := Default()
+		in.TrimSpace = tt.trim
+		in.RequireEquals = tt.require
+		in.Stream = strings.NewReader(stdin)
+		got, err := in.Pairs([]string{})
+		if !errors.Is(err, tt.err) {
+			t.Fatalf("Pairs() error = %v, want %v", err, tt.err)
+		}
+		if err != nil {
+			if !strings.Contains(err.Error(), `"FLAG"`) {
+				t.Errorf("Pairs() error = %v, want offending token", err)
+			}
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("TrimSpace=%t: Pairs() = %q, want %q", tt.trim, got, tt.want)
+		}
+	}
+}
+
+func TestInputExpandEnv(t *testing.T) {
+
+	t.Setenv("CLIN_SET", "value")
+	os.Unsetenv("CLIN_UNSET")
+
+	const stdin = "$CLIN_SET/logs\n${CLIN_SET}s\n[$CLIN_UNSET]\n$$\ncost: $\n"
+
+	in := Default()
+	in.Stream = strings.NewReader(stdin)
+	got := in.Args([]string{})
+	want := []string{"$CLIN_SET/logs", "${CLIN_SET}s", "[$CLIN_UNSET]", "$$", "cost: $"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ExpandEnv=false: Args() = %q, want %q", got, want)
+	}
+
+	in.ExpandEnv = true
+	in.Stream = strings.NewReader(stdin)
+	got = in.Args([]string{})
+	// "$$" refers to the (undefined) special variable "$".
+	want = []string{"value/logs", "values", "[]", "", "cost: $"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ExpandEnv=true: Args() = %q, want %q", got, want)
+	}
+
+	// Literal arguments are expanded, but file content is not.
+	path := writeTemp(t, "input.txt", "$CLIN_SET")
+	for _, tt := range []struct {
+		args []string
+		want string
+	}{
+		{[]string{"$CLIN_SET"}, "value"},
+		{[]string{"$CLIN_SET", "$CLIN_UNSET", "x"}, "value  x"},
+		{[]string{path}, "$CLIN_SET"},
+	} {
+		b, err := in.ReadAll(tt.args)
+		if err != nil {
+			t.Fatalf("ReadAll(%q) error = %v", tt.args, err)
+		}
+		if got := string(b); got != tt.want {
+			t.Errorf("ExpandEnv=true: Reader(%q) = %q, want %q", tt.args, got, tt.want)
+		}
+	}
+}
+
+func TestInputExpandTilde(t *testing.T) {
+
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home) // Windows
+	if err := os.WriteFile(filepath.Join(home, "x"), []byte("in home"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		arg    string
+		expand bool
+		want   string
+	}{
+		{arg: "~/x", expand: true, want: "in home"},
+		{arg: "~/x", expand: false, want: "~/x"},
+		{arg: "~/missing", expand: true, want: "~/missing"},
+		{arg: "a~/x", expand: true, want: "a~/x"},
+		{arg: "~x", expand: true, want: "~x"},
+	} {
+		in := Default()
+		in.ExpandTilde = tt.expand
+		b, err := in.ReadAll([]string{tt.arg})
+		if err != nil {
+			t.Fatalf("ReadAll(%q) error = %v", tt.arg, err)
+		}
+		if got := string(b); got != tt.want {
+			t.Errorf("ExpandTilde=%t: Reader(%q) = %q, want %q",
+				tt.expand, tt.arg, got, tt.want)
+		}
+	}
+}
+
+func TestInputPeek(t *testing.T) {
+
+	for _, tt := range []struct {
+		name  string
+		args  []string
+		stdin string
+		first string
+		rest  string
+		err   error
+	}{
+		{"stream", nil, "cmd\nsub\n\nargs\n", "cmd", "sub\n\nargs\n", nil},
+		{"stream-crlf", nil, "cmd\r\nrest", "cmd", "rest", nil},
+		{"stream-one", nil, "cmd", "cmd", "", nil},
+		{"stream-empty", nil, "", "", "", io.EOF},
+		{"args", []string{"cmd", "a", "b"}, "unused", "cmd", "a b", nil},
+		{"args-one", []string{"cmd"}, "unused", "cmd", "", nil},
+	} {
+		in := Default()
+		// Deliver the stream in small chunks, so that the scanner's buffer holds
+		// only part of the remaining input.
+		in.Stream = iotest.HalfReader(strings.NewReader(tt.stdin))
+		first, rest, err := in.Peek(tt.args)
+		if !errors.Is(err, tt.err) {
+			t.Fatalf("%s: Peek() error = %v, want %v", tt.name, err, tt.err)
+		}
+		if first != tt.first {
+			t.Errorf("%s: Peek() first = %q, want %q", tt.name, first, tt.first)
+		}
+		b, err := io.ReadAll(rest)
+		if err != nil {
+			t.Fatalf("%s: ReadAll(rest) error = %v", tt.name, err)
+		}
+		if string(b) != tt.rest {
+			t.Errorf("%s: Peek() rest = %q, want %q", tt.name, b, tt.rest)
+		}
+	}
+
+	// The first token skips comments, and the rest follows the comment.
+	in := Default()
+	in.CommentPrefix = []byte("#")
+	in.Stream = strings.NewReader("# comment\ncmd\nrest\n")
+	first, rest, err := in.Peek([]string{})
+	if err != nil {
+		t.Fatalf("Peek() error = %v", err)
+	}
+	if b, _ := io.ReadAll(rest); first != "cmd" || string(b) != "rest\n" {
+		t.Errorf("Peek() = %q, %q, want %q, %q", first, b, "cmd", "rest\n")
+	}
+}
+
+func TestInputArgsN(t *testing.T) {
+
+	const stdin = "a\nb\nc\nd\ne\n"
+
+	for _, tt := range []struct {
+		n      int
+		tokens []string
+		rest   []string
+	}{
+		{n: 0, tokens: []string{}, rest: []string{"a", "b", "c", "d", "e"}},
+		{n: 2, tokens: []string{"a", "b"}, rest: []string{"c", "d", "e"}},
+		{n: 5, tokens: []string{"a", "b", "c", "d", "e"}, rest: []string{}},
+		{n: 9, tokens: []string{"a", "b", "c", "d", "e"}, rest: []string{}},
+	} {
+		in := Default()
+		in.Stream = iotest.OneByteReader(strings.NewReader(stdin))
+		tokens, rest, err := in.ArgsN([]string{}, tt.n)
+		if err != nil {
+			t.Fatalf("ArgsN(%d) error = %v", tt.n, err)
+		}
+		if !reflect.DeepEqual(tokens, tt.tokens) {
+			t.Errorf("ArgsN(%d) tokens = %q, want %q", tt.n, tokens, tt.tokens)
+		}
+		// The remainder yields the leftover tokens.
+		in.Stream = rest
+		if got := in.Args([]string{}); !reflect.DeepEqual(got, tt.rest) {
+			t.Errorf("ArgsN(%d) rest = %q, want %q", tt.n, got, tt.rest)
+		}
+	}
+
+	// A large stream is buffered by the scanner beyond the tokens returned.
+	var b strings.Builder
+	for i := 0; i < 10000; i++ {
+		fmt.Fprintln(&b, i)
+	}
+	in := Default()
+	in.Stream = strings.NewReader(b.String())
+	tokens, rest, err := in.ArgsN([]string{}, 3)
+	if err != nil {
+		t.Fatalf("ArgsN(3) error = %v", err)
+	}
+	if want := []string{"0", "1", "2"}; !reflect.DeepEqual(tokens, want) {
+		t.Errorf("ArgsN(3) tokens = %q, want %q", tokens, want)
+	}
+	if got, _ := io.ReadAll(rest); "0\n1\n2\n"+string(got) != b.String() {
+		t.Errorf("ArgsN(3) rest has %d bytes, want %d", len(got), b.Len()-6)
+	}
+
+	tokens, rest, err = in.ArgsN([]string{"x", "y", "z"}, 2)
+	if err != nil {
+		t.Fatalf("ArgsN(2) error = %v", err)
+	}
+	if got, _ := io.ReadAll(rest); !reflect.DeepEqual(tokens, []string{"x", "y"}) || string(got) != "z" {
+		t.Errorf("ArgsN(2) = %q, %q, want [x y], z", tokens, got)
+	}
+}
+
+func TestInputConcurrent(t *testing.T) {
+
+	in := Default()
+	in.Stream = emptyReader{}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			if got := in.Args([]string{}); len(got) != 0 {
+				t.Errorf("Args() = %q, want []", got)
+			}
+		}()
+		go func(i int) {
+			defer wg.Done()
+			want := []string{fmt.Sprint(i), fmt.Sprint(-i)}
+			got, err := in.Lines([]string{want[0] + "\n" + want[1] + "\n"})
+			if err != nil || !reflect.DeepEqual(got, want) {
+				t.Errorf("Lines() = %q, %v, want %q, nil", got, err, want)
+			}
+		}(i)
+	}
+	wg.Wait()
+}
+
+func TestInputReaderErr(t *testing.T) {
+
+	dir := t.TempDir()
+	missing := filepath.Join(dir, "missing")
+
+	in := Default()
+	if _, err := in.ReaderErr([]string{dir}); !errors.Is(err, ErrIsDirectory) {
+		t.Errorf("ReaderErr(dir) error = %v, want %v", err, ErrIsDirectory)
+	}
+	var perr *fs.PathError
+	if _, err := in.ReaderCloser([]string{dir}); !errors.As(err, &perr) || perr.Path != dir {
+		t.Errorf("ReaderCloser(dir) error = %v, want *fs.PathError for %q", err, dir)
+	}
+	// Reader still falls back to the string itself.
+	if b, _ := io.ReadAll(in.Reader([]string{dir})); string(b) != dir {
+		t.Errorf("Reader(dir) = %q, want %q", b, dir)
+	}
+
+	r, err := in.ReaderErr([]string{missing})
+	if err != nil {
+		t.Fatalf("ReaderErr(missing) error = %v", err)
+	}
+	if b, _ := io.ReadAll(r); string(b) != missing {
+		t.Errorf("ReaderErr(missing) = %q, want %q", b, missing)
+	}
+
+	path := writeTemp(t, "unreadable", "secret")
+	if err := os.Chmod(path, 0); err != nil {
+		t.Fatal(err)
+	}
+	if f, err := os.Open(path); err == nil {
+		f.Close()
+		t.Skip("file permissions are not enforced for this user")
+	}
+	if _, err := in.ReaderErr([]string{path}); !errors.Is(err, fs.ErrPermission) {
+		t.Errorf("ReaderErr(unreadable) error = %v, want %v", err, fs.ErrPermission)
+	}
+}
+
+func TestInputCopy(t *testing.T) {
+
+	path := writeTemp(t, "input.txt", "file\ncontent\n")
+
+	for _, tt := range []struct {
+		name    string
+		args    []string
+		literal bool
+		want    string
+	}{
+		{"file", []string{path}, false, "file\ncontent\n"},
+		{"file-literal", []string{path}, true, path},
+		{"literal", []string{"literal"}, false, "literal"},
+		{"joined", []string{"a", "b", "c"}, false, "a b c"},
+		{"stdin", []string{}, false, "stdin\n"},
+	} {
+		in := Default()
+		in.Literal = tt.literal
+		in.Stream = strings.NewReader("stdin\n")
+		var b bytes.Buffer
+		n, err := in.Copy(tt.args, &b)
+		if err != nil {
+			t.Fatalf("%s: Copy() error = %v", tt.name, err)
+		}
+		if n != int64(len(tt.want)) {
+			t.Errorf("%s: Copy() = %d, want %d", tt.name, n, len(tt.want))
+		}
+		if got := b.String(); got != tt.want {
+			t.Errorf("%s: Copy() wrote %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestInputAllowURL(t *testing.T) {
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/ok" {
+			http.NotFound(w, r)
+			return
+		}
+		io.WriteString(w, "response body")
+	}))
+	defer srv.Close()
+
+	in := Default()
+	in.AllowURL = true
+	rc, err := in.ReaderCloser([]string{srv.URL + "/ok"})
+	if err != nil {
+		t.Fatalf("ReaderCloser() error = %v", err)
+	}
+	b, err := io.ReadAll(rc)
+	if err != nil {
+		t.Fatalf("ReadAll() error = %v", err)
+	}
+	if got, want := string(b), "response body"; got != want {
+		t.Errorf("ReaderCloser() = %q, want %q", got, want)
+	}
+	if err := rc.Close(); err != nil {
+		t.Errorf("Close() error = %v", err)
+	}
+
+	if _, err := in.ReaderErr([]string{srv.URL + "/missing"}); err == nil ||
+		!strings.Contains(err.Error(), "404") {
+		t.Errorf("ReaderErr(missing) error = %v, want 404 status", err)
+	}
+
+	in.AllowURL = false
+	if b, _ := io.ReadAll(in.Reader([]string{srv.URL + "/ok"})); string(b) != srv.URL+"/ok" {
+		t.Errorf("AllowURL=false: Reader() = %q, want %q", b, srv.URL+"/ok")
+	}
+}
+
+func TestInputCount(t *testing.T) {
+
+	for _, tt := range []struct {
+		stdin   string
+		comment string
+	}{
+		{stdin: ""},
+		{stdin: "\n"},
+		{stdin: "a"},
+		{stdin: "a\nb\n"},
+		{stdin: "a\n\n\nb\n\n"},
+		{stdin: "# a\nb\n# c\n", comment: "#"},
+	} {
+		in := Default()
+		in.CommentPrefix = []byte(tt.comment)
+		in.Stream = strings.NewReader(tt.stdin)
+		n, err := in.Count([]string{})
+		if err != nil {
+			t.Fatalf("Count(%q) error = %v", tt.stdin, err)
+		}
+		in.Stream = strings.NewReader(tt.stdin)
+		if want := len(in.Args([]string{})); n != want {
+			t.Errorf("Count(%q) = %d, want %d", tt.stdin, n, want)
+		}
+	}
+
+	in := Default()
+	if n, err := in.Count([]string{"a", "", "b"}); err != nil || n != 3 {
+		t.Errorf("Count(args) = %d, %v, want 3, nil", n, err)
+	}
+}
+
+func TestInputArgsChan(t *testing.T) {
+
+	const stdin = "a\nb\n\nc\nd\n"
+
+	in := Default()
+	in.Stream = strings.NewReader(stdin)
+	want := in.Args([]string{})
+
+	in.Stream = strings.NewReader(stdin)
+	tok, errc := in.ArgsChan(context.Background(), []string{})
+	got := []string{}
+	for s := range tok {
+		got = append(got, s)
+	}
+	if err := <-errc; err != nil {
+		t.Fatalf("ArgsChan() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ArgsChan() = %q, want %q", got, want)
+	}
+
+	// A consumer that stops early cancels the context.
+	ctx, cancel := context.WithCancel(context.Background())
+	in.Stream = strings.NewReader(stdin)
+	tok, errc = in.ArgsChan(ctx, []string{})
+	if s := <-tok; s != "a" {
+		t.Errorf("ArgsChan() first = %q, want %q", s, "a")
+	}
+	cancel()
+	if err := <-errc; !errors.Is(err, context.Canceled) {
+		t.Errorf("ArgsChan() error = %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestInputArgsOr(t *testing.T) {
+
+	def := []string{"default"}
+
+	for _, tt := range []struct {
+		name  string
+		args  []string
+		stdin string
+		want  []string
+	}{
+		{"empty-stream", []string{}, "", def},
+		// A single newline delimits one empty token.
+		{"newline-stream", []string{}, "\n", []string{""}},
+		{"whitespace-stream", []string{}, "  \t", []string{"  \t"}},
+		{"stream", []string{}, "a\nb\n", []string{"a", "b"}},
+		{"args", []string{"x"}, "", []string{"x"}},
+		{"empty-arg", []string{""}, "", []string{""}},
+	} {
+		in := Default()
+		in.Stream = strings.NewReader(tt.stdin)
+		if got := in.ArgsOr(tt.args, def); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: ArgsOr() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestInputReaderOr(t *testing.T) {
+
+	empty := writeTemp(t, "empty.txt", "")
+	full := writeTemp(t, "full.txt", "content")
+
+	for _, tt := range []struct {
+		name  string
+		args  []string
+		stdin string
+		want  string
+	}{
+		{"empty-stream", []string{}, "", "default"},
+		{"whitespace-stream", []string{}, " \n", " \n"},
+		{"stream", []string{}, "stdin", "stdin"},
+		{"empty-file", []string{empty}, "", "default"},
+		{"file", []string{full}, "", "content"},
+		{"empty-arg", []string{""}, "", "default"},
+		{"arg", []string{"x"}, "", "x"},
+	} {
+		in := Default()
+		in.Stream = strings.NewReader(tt.stdin)
+		b, err := io.ReadAll(in.ReaderOr(tt.args, strings.NewReader("default")))
+		if err != nil {
+			t.Fatalf("%s: ReadAll() error = %v", tt.name, err)
+		}
+		if got := string(b); got != tt.want {
+			t.Errorf("%s: ReaderOr() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+
+	// A file whose first Read fails is closed.
+	fail := errors.New("fail")
+	var closed bool
+	in := Default()
+	in.OpenFunc = func(string) (io.ReadCloser, error) {
+		r := &failReader{r: strings.NewReader(""), err: fail}
+		return readCloser{r, closerFunc(func() error { closed = true; return nil })}, nil
+	}
+	if _, err := io.ReadAll(in.ReaderOr([]string{"file"}, nil)); err != fail {
+		t.Errorf("ReaderOr(failing) error = %v, want %v", err, fail)
+	}
+	if !closed {
+		t.Errorf("ReaderOr(failing) did not close the file")
+	}
+}
+
+func TestInputValidate(t *testing.T) {
+
+	for _, tt := range []struct {
+		stdin string
+		trim  bool
+		want  []string
+		bad   string
+	}{
+		{stdin: "abc\ndef\n", want: []string{"abc", "def"}},
+		{stdin: "abc\nd3f\nghi\n", want: []string{"abc"}, bad: `token 2 "d3f"`},
+		{stdin: "abc\n def \n", want: []string{"abc"}, bad: `token 2 " def "`},
+		{stdin: "abc\n def \n", trim: true, want: []string{"abc", "def"}},
+		{stdin: "abc\n\n", want: []string{"abc"}, bad: `token 2 ""`},
+	} {
+		in := Default()
+		in.Validate = regexp.MustCompile(`^[a-z]+$`)
+		in.TrimSpace = tt.trim
+		in.Stream = strings.NewReader(tt.stdin)
+		got, err := in.ArgsErr([]string{})
+		if tt.bad == "" && err != nil {
+			t.Errorf("ArgsErr(%q) error = %v, want nil", tt.stdin, err)
+		}
+		if tt.bad != "" && (!errors.Is(err, ErrInvalidToken) || !strings.Contains(err.Error(), tt.bad)) {
+			t.Errorf("ArgsErr(%q) error = %v, want %v naming %s",
+				tt.stdin, err, ErrInvalidToken, tt.bad)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ArgsErr(%q) = %q, want %q", tt.stdin, got, tt.want)
+		}
+	}
+}
+
+func TestInputInlineCommentPrefix(t *testing.T) {
+
+	for _, tt := range []struct {
+		trim  bool
+		shell bool
+		stdin string
+		want  []string
+	}{
+		{stdin: "abc # trailing\n", want: []string{"abc "}},
+		{trim: true, stdin: "abc # trailing\n", want: []string{"abc"}},
+		{trim: true, stdin: "# whole\ndef\n", want: []string{"", "def"}},
+		{stdin: "a#b#c\nd\n", want: []string{"a", "d"}},
+		{shell: true, stdin: `a "b # c" d`, want: []string{"a", "b # c", "d"}},
+	} {
+		in := Default()
+		in.InlineCommentPrefix = []byte("#")
+		in.TrimSpace = tt.trim
+		in.ShellSplit = tt.shell
+		in.Stream = strings.NewReader(tt.stdin)
+		if got := in.Args([]string{}); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Args(%q) = %q, want %q", tt.stdin, got, tt.want)
+		}
+	}
+
+	in := Default()
+	in.InlineCommentPrefix = []byte("//")
+	in.TrimSpace = true
+	in.Stream = strings.NewReader("// whole\nx // y\n")
+	if got, want := in.Fields([]string{}), []string{"x"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Fields() = %q, want %q", got, want)
+	}
+}
+
+func TestInputTrimAffix(t *testing.T) {
+
+	for _, tt := range []struct {
+		prefix, suffix string
+		stdin          string
+		want           []string
+	}{
+		{`"`, `"`, "\"a\"\nb\n\"c\nd\"\n\"\"e\"\"\n\"\n", []string{"a", "b", "c", "d", "\"e\"", ""}},
+		{"[", "]", "[b]\nb\n[[b]]\n]b[\n", []string{"b", "b", "[b]", "]b["}},
+		{"", "]", "[b]\n", []string{"[b"}},
+	} {
+		in := Default()
+		in.TrimPrefix = []byte(tt.prefix)
+		in.TrimSuffix = []byte(tt.suffix)
+		in.Stream = strings.NewReader(tt.stdin)
+		if got := in.Args([]string{}); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("TrimPrefix=%q, TrimSuffix=%q: Args(%q) = %q, want %q",
+				tt.prefix, tt.suffix, tt.stdin, got, tt.want)
+		}
+	}
+
+	// Tokens that become empty are removed by Fields.
+	in := Default()
+	in.TrimPrefix, in.TrimSuffix = []byte("["), []byte("]")
+	got, want := in.Fields([]string{"[a]", "[]", "b"}), []string{"a", "b"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Fields() = %q, want %q", got, want)
+	}
+}
+
+func TestInputReset(t *testing.T) {
+
+	in, def := Default(), Default()
+
+	// Set every exported field to a value different from the default.
+	v, d := reflect.ValueOf(&in).Elem(), reflect.ValueOf(def)
+	for i := 0; i < v.NumField(); i++ {
+		f, sf := v.Field(i), v.Type().Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+		switch f.Kind() {
+		case reflect.Bool:
+			f.SetBool(!f.Bool())
+		case reflect.Int, reflect.Int32, reflect.Int64:
+			f.SetInt(f.Int() + 7)
+		case reflect.Uint8:
+			f.SetUint(f.Uint() + 7)
+		case reflect.String:
+			f.SetString(f.String() + "x")
+		case reflect.Slice:
+			f.Set(reflect.MakeSlice(f.Type(), 1, 1))
+		case reflect.Pointer:
+			f.Set(reflect.New(f.Type().Elem()))
+		case reflect.Func:
+			f.Set(reflect.MakeFunc(f.Type(), func(a []reflect.Value) []reflect.Value {
+				return a
+			}))
+		case reflect.Interface:
+			f.Set(reflect.ValueOf(&bytes.Buffer{}))
+		default:
+			t.Fatalf("unhandled field %s of kind %s", sf.Name, f.Kind())
+		}
+		if reflect.DeepEqual(f.Interface(), d.Field(i).Interface()) &&
+			f.Kind() != reflect.Func {
+			t.Fatalf("field %s was not modified", sf.Name)
+		}
+	}
+	if reflect.DeepEqual(in, Default()) {
+		t.Fatal("modified Input is equal to Default()")
+	}
+
+	in.Reset()
+	if !reflect.DeepEqual(in, Default()) {
+		t.Errorf("Reset() = %+v, want %+v", in, Default())
+	}
+	if in.Stream != os.Stdin {
+		t.Errorf("Reset() Stream = %v, want os.Stdin", in.Stream)
+	}
+}
+
+func TestInputSource(t *testing.T) {
+
+	path := writeTemp(t, "input.txt", "content")
+	dir := filepath.Dir(path)
+
+	for _, tt := range []struct {
+		name string
+		args []string
+		opt  func(*Input)
+		want Source
+	}{
+		{"stream", []string{}, nil, SourceStream},
+		{"literal", []string{"text"}, nil, SourceArgs},
+		{"joined", []string{path, path}, nil, SourceArgs},
+		{"file", []string{path}, nil, SourceFile},
+		{"file-literal", []string{path}, func(in *Input) { in.Literal = true }, SourceArgs},
+		{"multifile", []string{path, path}, func(in *Input) { in.MultiFile = true }, SourceFile},
+		{"glob", []string{filepath.Join(dir, "*.txt")}, func(in *Input) { in.ExpandGlob = true }, SourceFile},
+		{"glob-off", []string{filepath.Join(dir, "*.txt")}, nil, SourceArgs},
+		{"url", []string{"https://example.com/x"}, func(in *Input) { in.AllowURL = true }, SourceURL},
+		{"url-off", []string{"https://example.com/x"}, nil, SourceArgs},
+	} {
+		in := Default()
+		if tt.opt != nil {
+			tt.opt(&in)
+		}
+		if got := in.Source(tt.args); got != tt.want {
+			t.Errorf("%s: Source() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestInputArgsJSON(t *testing.T) {
+
+	for _, tt := range []struct {
+		stdin string
+		want  []string
+		err   bool
+	}{
+		{stdin: `["a","b"]`, want: []string{"a", "b"}},
+		{stdin: " [ \"a b\", \"\", \"\\u00e9\\n\" ]\n", want: []string{"a b", "", "é\n"}},
+		{stdin: `[]`, want: []string{}},
+		{stdin: `null`, want: []string{}},
+		{stdin: ``, err: true},
+		{stdin: `["a",`, err: true},
+		{stdin: `["a", 1]`, err: true},
+		{stdin: `{"a": "b"}`, err: true},
+		{stdin: `["a"] ["b"]`, err: true},
+	} {
+		in := Default()
+		in.Stream = strings.NewReader(tt.stdin)
+		got, err := in.ArgsJSON([]string{})
+		if (err != nil) != tt.err {
+			t.Errorf("ArgsJSON(%q) error = %v, want error = %t", tt.stdin, err, tt.err)
+			continue
+		}
+		if !tt.err && !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ArgsJSON(%q) = %q, want %q", tt.stdin, got, tt.want)
+		}
+	}
+
+	in := Default()
+	in.Stream = strings.NewReader(`["unused"]`)
+	got, err := in.ArgsJSON([]string{"x", "y"})
+	if err != nil || !reflect.DeepEqual(got, []string{"x", "y"}) {
+		t.Errorf("ArgsJSON(args) = %q, %v, want [x y], nil", got, err)
+	}
+
+	in.DashStdin = true
+	in.Stream = strings.NewReader(`["-"]`)
+	got, err = in.ArgsJSON([]string{"-"})
+	if err != nil || !reflect.DeepEqual(got, []string{"-"}) {
+		t.Errorf("ArgsJSON(DashStdin) = %q, %v, want [-], nil", got, err)
+	}
+}
+
+func TestInputArgsCSV(t *testing.T) {
+
+	path := writeTemp(t, "input.tsv", "name\tnote\nalice\t\"tab\there\"\n")
+
+	for _, tt := range []struct {
+		name  string
+		comma rune
+		args  []string
+		stdin string
+		want  [][]string
+	}{
+		{
+			name:  "comma",
+			args:  []string{},
+			stdin: "a,b,c\r\n1,\"two, 2\",3\n\"multi\nline\",\"\"\"q\"\"\"\n",
+			want:  [][]string{{"a", "b", "c"}, {"1", "two, 2", "3"}, {"multi\nline", `"q"`}},
+		},
+		{
+			name:  "tab-file",
+			comma: '\t',
+			args:  []string{path},
+			want:  [][]string{{"name", "note"}, {"alice", "tab\there"}},
+		},
+		{
+			name:  "zero-comma",
+			comma: 0,
+			args:  []string{"x,y"},
+			want:  [][]string{{"x", "y"}},
+		},
+		{
+			name: "empty",
+			args: []string{},
+			want: [][]string{},
+		},
+	} {
+		in := Default()
+		in.CSVComma = tt.comma
+		in.Stream = strings.NewReader(tt.stdin)
+		got, err := in.ArgsCSV(tt.args)
+		if err != nil {
+			t.Fatalf("%s: ArgsCSV() error = %v", tt.name, err)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: ArgsCSV() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+
+	in := Default()
+	in.Stream = strings.NewReader("a,\"b\n")
+	if _, err := in.ArgsCSV([]string{}); err == nil {
+		t.Errorf("ArgsCSV(unterminated) error = nil, want error")
+	}
+}
+
+func TestInputInts(t *testing.T) {
+
+	for _, tt := range []struct {
+		stdin string
+		want  []int
+		bad   string
+	}{
+		{stdin: "1\n-2\n30\n", want: []int{1, -2, 30}},
+		{stdin: "4\n\n5\n\n", want: []int{4, 5}},
+		{stdin: "6\nseven\n8\n", want: []int{6}, bad: `token 2 "seven"`},
+	} {
+		in := Default()
+		in.Stream = strings.NewReader(tt.stdin)
+		got, err := in.Ints([]string{})
+		if tt.bad == "" && err != nil {
+			t.Errorf("Ints(%q) error = %v, want nil", tt.stdin, err)
+		}
+		if tt.bad != "" && (!errors.Is(err, ErrInvalidToken) || !strings.Contains(err.Error(), tt.bad)) {
+			t.Errorf("Ints(%q) error = %v, want %v naming %s",
+				tt.stdin, err, ErrInvalidToken, tt.bad)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Ints(%q) = %v, want %v", tt.stdin, got, tt.want)
+		}
+	}
+
+	in := Default()
+	got, err := in.Floats([]string{"1.5", "", "-2e3"})
+	if want := []float64{1.5, -2000}; err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("Floats() = %v, %v, want %v, nil", got, err, want)
+	}
+	if _, err := in.Floats([]string{"1", "x"}); !errors.Is(err, ErrInvalidToken) ||
+		!strings.Contains(err.Error(), `token 2 "x"`) {
+		t.Errorf("Floats() error = %v, want %v", err, ErrInvalidToken)
+	}
+}
+
+func TestInputScan(t *testing.T) {
+
+	var (
+		i int
+		s string
+		b bool
+		f float64
+	)
+	in := Default()
+	in.Stream = strings.NewReader("42\nhello world\ntrue\n2.5\nextra\n")
+	n, err := in.Scan([]string{}, &i, &s, &b, &f)
+	if err != nil {
+		t.Fatalf("Scan() error = %v", err)
+	}
+	if n != 4 || i != 42 || s != "hello world" || !b || f != 2.5 {
+		t.Errorf("Scan() = %d (%d, %q, %t, %g), want 4 (42, %q, true, 2.5)",
+			n, i, s, b, f, "hello world")
+	}
+
+	n, err = in.Scan([]string{"7", "maybe"}, &i, &b)
+	if n != 1 || i != 7 || !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("Scan(mismatch) = %d, %v, want 1, %v", n, err, ErrInvalidToken)
+	} else if !strings.Contains(err.Error(), `"maybe"`) {
+		t.Errorf("Scan(mismatch) error = %v, want offending token", err)
+	}
+
+	n, err = in.Scan([]string{"x"}, &s, &i)
+	if n != 1 || s != "x" || err != io.ErrUnexpectedEOF {
+		t.Errorf("Scan(short) = %d, %v, want 1, %v", n, err, io.ErrUnexpectedEOF)
+	}
+}
+
+func TestInputMaxBytes(t *testing.T) {
+
+	for _, tt := range []struct {
+		name string
+		max  int64
+		want []string
+		err  error
+	}{
+		{name: "mid-token", max: 5, want: []string{"abc", "d"}, err: ErrInputTooLarge},
+		{name: "at-delim", max: 4, want: []string{"abc"}, err: ErrInputTooLarge},
+		{name: "exact", max: 8, want: []string{"abc", "def"}},
+		{name: "larger", max: 64, want: []string{"abc", "def"}},
+		{name: "unlimited", max: 0, want: []string{"abc", "def"}},
+	} {
+		in := Default()
+		in.MaxBytes = tt.max
+		in.Stream = iotest.HalfReader(strings.NewReader("abc\ndef\n"))
+		got, err := in.ArgsErr([]string{})
+		if !errors.Is(err, tt.err) {
+			t.Errorf("%s: ArgsErr() error = %v, want %v", tt.name, err, tt.err)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: ArgsErr() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+
+	in := Default()
+	in.MaxBytes = 5
+	in.Stream = strings.NewReader("abc\ndef\n")
+	b, err := io.ReadAll(in.Reader([]string{}))
+	if string(b) != "abc\nd" || err != ErrInputTooLarge {
+		t.Errorf("Reader() = %q, %v, want %q, %v", b, err, "abc\nd", ErrInputTooLarge)
+	}
+	in.Stream = strings.NewReader("abc\n")
+	if b, err := in.ReadAll([]string{}); string(b) != "abc\n" || err != nil {
+		t.Errorf("ReadAll(small) = %q, %v, want %q, nil", b, err, "abc\n")
+	}
+}
+
+func TestInputFieldsTrim(t *testing.T) {
+
+	args := []string{"", "a", " ", "\t", " b ", "\u00a0"}
+	in := Default()
+	if got, want := in.Fields(args), []string{"a", " ", "\t", " b ", "\u00a0"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Fields() = %q, want %q", got, want)
+	}
+	if got, want := in.FieldsTrim(args), []string{"a", " b "}; !reflect.DeepEqual(got, want) {
+		t.Errorf("FieldsTrim() = %q, want %q", got, want)
+	}
+}
+
+func TestInputJoinFunc(t *testing.T) {
+
+	quote := func(a []string) string {
+		q := make([]string, len(a))
+		for i, s := range a {
+			q[i] = strconv.Quote(s)
+		}
+		return strings.Join(q, ",")
+	}
+	for _, tt := range []struct {
+		name string
+		join func([]string) string
+		args []string
+		want string
+	}{
+		{name: "nil", args: []string{"a b", "c"}, want: "a b c"},
+		{name: "quote", join: quote, args: []string{"a b", `c"d`}, want: `"a b","c\"d"`},
+		{name: "single", join: quote, args: []string{"a b"}, want: "a b"},
+	} {
+		in := Default()
+		in.JoinFunc = tt.join
+		b, err := io.ReadAll(in.Reader(tt.args))
+		if err != nil {
+			t.Fatalf("%s: Reader() error = %v", tt.name, err)
+		}
+		if string(b) != tt.want {
+			t.Errorf("%s: Reader(%q) = %q, want %q", tt.name, tt.args, b, tt.want)
+		}
+	}
+}
+
+func TestInputDashStdin(t *testing.T) {
+
+	for _, tt := range []struct {
+		dash   bool
+		args   []string
+		tokens []string
+		read   string
+		source Source
+	}{
+		{dash: false, args: []string{"-"}, tokens: []string{"-"}, read: "-", source: SourceArgs},
+		{dash: true, args: []string{"-"}, tokens: []string{"a", "b"}, read: "a\nb\n", source: SourceStream},
+		{dash: true, args: []string{"-", "-"}, tokens: []string{"-", "-"}, read: "- -", source: SourceArgs},
+	} {
+		in := Default()
+		in.DashStdin = tt.dash
+		in.Stream = strings.NewReader("a\nb\n")
+		if got := in.Args(tt.args); !reflect.DeepEqual(got, tt.tokens) {
+			t.Errorf("DashStdin=%t: Args(%q) = %q, want %q", tt.dash, tt.args, got, tt.tokens)
+		}
+		in.Stream = strings.NewReader("a\nb\n")
+		if b, err := io.ReadAll(in.Reader(tt.args)); err != nil || string(b) != tt.read {
+			t.Errorf("DashStdin=%t: Reader(%q) = %q, %v, want %q, nil",
+				tt.dash, tt.args, b, err, tt.read)
+		}
+		if got := in.Source(tt.args); got != tt.source {
+			t.Errorf("DashStdin=%t: Source(%q) = %v, want %v", tt.dash, tt.args, got, tt.source)
+		}
+	}
+}
+
+func TestInputSort(t *testing.T) {
+
+	numeric := func(a, b string) bool {
+		x, _ := strconv.Atoi(a)
+		y, _ := strconv.Atoi(b)
+		return x < y
+	}
+	for _, tt := range []struct {
+		name  string
+		sort  bool
+		fn    func(a, b string) bool
+		stdin string
+		want  []string
+	}{
+		{name: "unsorted", stdin: "10\n9\n 2 \n10\n\n", want: []string{"10", "9", "2"}},
+		{name: "lexical", sort: true, stdin: "10\n9\n 2 \n10\n\n", want: []string{"10", "2", "9"}},
+		{name: "numeric", fn: numeric, stdin: "10\n9\n 2 \n10\n\n", want: []string{"2", "9", "10"}},
+		{name: "stable", fn: numeric, stdin: "b\n1\na\n", want: []string{"b", "a", "1"}},
+	} {
+		in := Default()
+		in.Sort = tt.sort
+		in.SortFunc = tt.fn
+		in.TrimSpace = true
+		in.Unique = true
+		in.Stream = strings.NewReader(tt.stdin)
+		if got := in.Fields([]string{}); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: Fields() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+
+	in := Default()
+	in.Sort = true
+	got, err := in.ArgsContext(context.Background(), []string{"c", "a", "b"})
+	if want := []string{"a", "b", "c"}; err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("ArgsContext() = %q, %v, want %q, nil", got, err, want)
+	}
+}
+
+func TestInputArgsRaw(t *testing.T) {
+
+	const content = "# header\r\n one \n\ntwo\r\n"
+	in := Default()
+	in.CommentPrefix = []byte("#")
+	in.TrimSpace = true
+	in.Stream = iotest.OneByteReader(strings.NewReader(content))
+	tokens, raw, err := in.ArgsRaw([]string{})
+	if err != nil {
+		t.Fatalf("ArgsRaw() error = %v", err)
+	}
+	if want := []string{"one", "", "two"}; !reflect.DeepEqual(tokens, want) {
+		t.Errorf("ArgsRaw() tokens = %q, want %q", tokens, want)
+	}
+	if string(raw) != content {
+		t.Errorf("ArgsRaw() raw = %q, want %q", raw, content)
+	}
+
+	tokens, raw, err = in.ArgsRaw([]string{" a", "b "})
+	if want := []string{"a", "b"}; err != nil || !reflect.DeepEqual(tokens, want) {
+		t.Errorf("ArgsRaw(args) tokens = %q, %v, want %q, nil", tokens, err, want)
+	}
+	if want := " a b "; string(raw) != want {
+		t.Errorf("ArgsRaw(args) raw = %q, want %q", raw, want)
+	}
+
+	// IdleTimeout is ignored, so that raw is complete and not modified after
+	// ArgsRaw returns.
+	pr, pw := io.Pipe()
+	go func() {
+		defer pw.Close()
+		for _, s := range []string{"a\n", "b\n"} {
+			time.Sleep(50 * time.Millisecond)
+			_, _ = io.WriteString(pw, s)
+		}
+	}()
+	in = Default()
+	in.IdleTimeout = 10 * time.Millisecond
+	in.Stream = pr
+	tokens, raw, err = in.ArgsRaw([]string{})
+	if want := []string{"a", "b"}; err != nil || !reflect.DeepEqual(tokens, want) {
+		t.Errorf("ArgsRaw(IdleTimeout) tokens = %q, %v, want %q, nil", tokens, err, want)
+	}
+	if want := "a\nb\n"; string(raw) != want {
+		t.Errorf("ArgsRaw(IdleTimeout) raw = %q, want %q", raw, want)
+	}
+}
+
+// flakyReader fails the first fails calls to Read, and then reads from r.
+type flakyReader struct {
+	r     io.Reader
+	fails int
+	calls int
+}
+
+func (f *flakyReader) Read(p []byte) (int, error) {
+	f.calls++
+	if f.calls <= f.fails {
+		return 0, errors.New("transient")
+	}
+	return f.r.Read(p)
+}
+
+func TestInputRetryReads(t *testing.T) {
+
+	for _, tt := range []struct {
+		retry int
+		want  []string
+		fail  bool
+	}{
+		{retry: 0, want: []string{}, fail: true},
+		{retry: 1, want: []string{}, fail: true},
+		{retry: 2, want: []string{"a", "b"}},
+		{retry: 5, want: []string{"a", "b"}},
+	} {
+		in := Default()
+		in.RetryReads = tt.retry
+		in.Stream = &flakyReader{r: strings.NewReader("a\nb\n"), fails: 2}
+		got, err := in.ArgsErr([]string{})
+		if (err != nil) != tt.fail {
+			t.Errorf("RetryReads=%d: ArgsErr() error = %v, want failure %t",
+				tt.retry, err, tt.fail)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("RetryReads=%d: ArgsErr() = %q, want %q", tt.retry, got, tt.want)
+		}
+	}
+
+	// EOF is never retried.
+	f := &flakyReader{r: strings.NewReader("")}
+	in := Default()
+	in.RetryReads = 3
+	in.Stream = f
+	if _, err := in.ArgsErr([]string{}); err != nil || f.calls != 1 {
+		t.Errorf("ArgsErr(EOF) error = %v after %d reads, want nil after 1", err, f.calls)
+	}
+}
+
+func TestInputArgsFromFile(t *testing.T) {
+
+	path := writeTemp(t, "tokens.txt", "a\r\nb\n")
+	in := Default()
+	in.Stream = strings.NewReader("x\ny\n")
+
+	got, err := in.ArgsFromFile(path)
+	if want := []string{"a", "b"}; err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("ArgsFromFile(%q) = %q, %v, want %q, nil", path, got, err, want)
+	}
+	got, err = in.ArgsFromFile("")
+	if want := []string{"x", "y"}; err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("ArgsFromFile(\"\") = %q, %v, want %q, nil", got, err, want)
+	}
+	missing := filepath.Join(t.TempDir(), "missing")
+	if _, err := in.ArgsFromFile(missing); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("ArgsFromFile(%q) error = %v, want %v", missing, err, fs.ErrNotExist)
+	}
+}
+
+func TestInputSplit(t *testing.T) {
+
+	in := Default()
+	in.Split = bufio.ScanWords
+	in.ShellSplit = true
+	in.Stream = iotest.HalfReader(strings.NewReader("  one two\n\n'three four'\r\n"))
+	got, err := in.ArgsErr([]string{})
+	if want := []string{"one", "two", "'three", "four'"}; err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("ArgsErr() = %q, %v, want %q, nil", got, err, want)
+	}
+}
+
+func TestInputReplace(t *testing.T) {
+
+	const content = "a\tb\t\tc\n\td\n"
+	const want = "a  b    c\n  d\n"
+	path := writeTemp(t, "tabs.txt", content)
+
+	for _, tt := range []struct {
+		name   string
+		args   []string
+		stream io.Reader
+		want   string
+	}{
+		{name: "file", args: []string{path}, want: want},
+		{name: "literal", args: []string{"x\ty", "z\t"}, want: "x  y z  "},
+		{name: "stream", args: []string{},
+			stream: iotest.OneByteReader(strings.NewReader(content)), want: want},
+	} {
+		in := Default()
+		in.Replace = strings.NewReplacer("\t", "  ")
+		in.Stream = tt.stream
+		b, err := io.ReadAll(in.Reader(tt.args))
+		if err != nil {
+			t.Fatalf("%s: Reader() error = %v", tt.name, err)
+		}
+		if string(b) != tt.want {
+			t.Errorf("%s: Reader() = %q, want %q", tt.name, b, tt.want)
+		}
+	}
+}
+
+func TestInputNormalizeNewlines(t *testing.T) {
+
+	const content = "crlf\r\ncr\rlf\n\r\r\n\n\rend\r"
+	const want = "crlf\ncr\nlf\n\n\n\n\nend\n"
+	path := writeTemp(t, "mixed.txt", content)
+
+	for _, tt := range []struct {
+		name   string
+		norm   bool
+		args   []string
+		stream io.Reader
+		want   string
+	}{
+		{name: "off", norm: false, args: []string{path}, want: content},
+		{name: "file", norm: true, args: []string{path}, want: want},
+		{name: "literal", norm: true, args: []string{"a\r\nb", "c\r"}, want: "a\nb c\n"},
+		{name: "stream", norm: true, args: []string{},
+			stream: iotest.OneByteReader(strings.NewReader(content)), want: want},
+	} {
+		in := Default()
+		in.NormalizeNewlines = tt.norm
+		in.Stream = tt.stream
+		b, err := io.ReadAll(in.Reader(tt.args))
+		if err != nil {
+			t.Fatalf("%s: Reader() error = %v", tt.name, err)
+		}
+		if string(b) != tt.want {
+			t.Errorf("%s: Reader() = %q, want %q", tt.name, b, tt.want)
+		}
+	}
+}
+
+func TestInputTee(t *testing.T) {
+
+	const content = "a\r\nb\nc"
+	var tee bytes.Buffer
+	in := Default()
+	in.Tee = &tee
+	in.Stream = iotest.HalfReader(strings.NewReader(content))
+	if got, want := in.Args([]string{}), []string{"a", "b", "c"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Args() = %q, want %q", got, want)
+	}
+	if tee.String() != content {
+		t.Errorf("Args(): Tee = %q, want %q", tee.String(), content)
+	}
+
+	tee.Reset()
+	in.Stream = strings.NewReader(content)
+	if b, err := io.ReadAll(in.Reader([]string{})); err != nil || string(b) != content {
+		t.Errorf("Reader() = %q, %v, want %q, nil", b, err, content)
+	}
+	if tee.String() != content {
+		t.Errorf("Reader(): Tee = %q, want %q", tee.String(), content)
+	}
+
+	tee.Reset()
+	_ = in.Args([]string{"x"})
+	_, _ = io.ReadAll(in.Reader([]string{"x", "y"}))
+	if tee.Len() != 0 {
+		t.Errorf("Reader(literal): Tee = %q, want empty", tee.String())
+	}
+
+	// Lines writes Stream to Tee once.
+	want := []string{"a", "b", "c"}
+	tee.Reset()
+	in.Stream = strings.NewReader(content)
+	if got, err := in.Lines([]string{}); err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("Lines() = %q, %v, want %q, nil", got, err, want)
+	}
+	if tee.String() != content {
+		t.Errorf("Lines(): Tee = %q, want %q", tee.String(), content)
+	}
+
+	// Neither Tee nor MaxBytes applies to a file read by Lines or ArgsFromFile.
+	path := writeTemp(t, "tee.txt", content)
+	in.MaxBytes = 2
+	tee.Reset()
+	if got, err := in.Lines([]string{path}); err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("Lines(file) = %q, %v, want %q, nil", got, err, want)
+	}
+	if got, err := in.ArgsFromFile(path); err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("ArgsFromFile() = %q, %v, want %q, nil", got, err, want)
+	}
+	if tee.Len() != 0 {
+		t.Errorf("Lines(file), ArgsFromFile(): Tee = %q, want empty", tee.String())
+	}
+}
+
+func TestInputPartition(t *testing.T) {
+
+	for _, tt := range []struct {
+		args  []string
+		flags []string
+		pos   []string
+	}{
+		{args: []string{}, flags: []string{}, pos: []string{}},
+		{
+			args:  []string{"a", "-x", "--foo=bar", "b", "-", "-vv"},
+			flags: []string{"-x", "--foo=bar", "-vv"},
+			pos:   []string{"a", "b", "-"},
+		},
+		{
+			args:  []string{"-x", "--", "-y", "--", "c"},
+			flags: []string{"-x"},
+			pos:   []string{"-y", "--", "c"},
+		},
+		{args: []string{"--"}, flags: []string{}, pos: []string{}},
+	} {
+		in := Default()
+		in.Stream = strings.NewReader("")
+		flags, pos := in.Partition(tt.args)
+		if !reflect.DeepEqual(flags, tt.flags) || !reflect.DeepEqual(pos, tt.pos) {
+			t.Errorf("Partition(%q) = %q, %q, want %q, %q", tt.args, flags, pos, tt.flags, tt.pos)
+		}
+	}
+}
+
+func TestInputFilter(t *testing.T) {
+
+	in := Default()
+	in.TrimSpace = true
+	in.Stream = strings.NewReader(" foo.go \nbar.txt\n\tbaz.go\ngo\n")
+	got := in.Filter([]string{}, func(s string) bool { return strings.HasSuffix(s, ".go") })
+	if want := []string{"foo.go", "baz.go"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Filter() = %q, want %q", got, want)
+	}
+	got = in.Filter([]string{"a", "b"}, func(string) bool { return false })
+	if want := []string{}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Filter(none) = %q, want %q", got, want)
+	}
+}
+
+func TestInputOpenFunc(t *testing.T) {
+
+	errDenied := errors.New("denied")
+	open := func(name string) (io.ReadCloser, error) {
+		switch name {
+		case "virtual":
+			return io.NopCloser(strings.NewReader("virtual\ncontent\n")), nil
+		case "secret":
+			return nil, &fs.PathError{Op: "open", Path: name, Err: errDenied}
+		}
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	// A real file is not consulted when OpenFunc is set.
+	path := writeTemp(t, "real", "real content")
+
+	for _, tt := range []struct {
+		arg    string
+		want   string
+		err    error
+		source Source
+	}{
+		{arg: "virtual", want: "virtual\ncontent\n", source: SourceFile},
+		{arg: "literal", want: "literal", source: SourceArgs},
+		{arg: path, want: path, source: SourceArgs},
+		{arg: "secret", err: errDenied, source: SourceFile},
+	} {
+		in := Default()
+		in.OpenFunc = open
+		b, err := in.ReadAll([]string{tt.arg})
+		if !errors.Is(err, tt.err) {
+			t.Errorf("ReadAll(%q) error = %v, want %v", tt.arg, err, tt.err)
+		}
+		if string(b) != tt.want {
+			t.Errorf("ReadAll(%q) = %q, want %q", tt.arg, b, tt.want)
+		}
+		if got := in.Source([]string{tt.arg}); got != tt.source {
+			t.Errorf("Source(%q) = %v, want %v", tt.arg, got, tt.source)
+		}
+	}
+}
+
+func TestInputReaderFS(t *testing.T) {
+
+	fsys := fstest.MapFS{
+		"a.txt":     {Data: []byte("alpha\n")},
+		"dir/b.txt": {Data: []byte("beta\n")},
+	}
+	for _, tt := range []struct {
+		literal bool
+		args    []string
+		want    string
+		err     error
+	}{
+		{args: []string{"a.txt"}, want: "alpha\n"},
+		{args: []string{"dir/b.txt"}, want: "beta\n"},
+		{args: []string{"missing.txt"}, want: "missing.txt"},
+		{args: []string{"./a.txt"}, want: "./a.txt"},
+		{args: []string{"a.txt", "dir/b.txt"}, want: "a.txt dir/b.txt"},
+		{args: []string{"dir"}, err: ErrIsDirectory},
+		{literal: true, args: []string{"a.txt"}, want: "a.txt"},
+	} {
+		in := Default()
+		in.Literal = tt.literal
+		r, err := in.ReaderFS(fsys, tt.args)
+		if !errors.Is(err, tt.err) {
+			t.Errorf("ReaderFS(%q) error = %v, want %v", tt.args, err, tt.err)
+		}
+		if err != nil {
+			continue
+		}
+		if b, err := io.ReadAll(r); err != nil || string(b) != tt.want {
+			t.Errorf("ReaderFS(%q) = %q, %v, want %q, nil", tt.args, b, err, tt.want)
+		}
+	}
+}
+
+func TestInputSkipLeadingEmpty(t *testing.T) {
+
+	const content = "\n \n\na\n\nb\n\n\n"
+	for _, tt := range []struct {
+		skip bool
+		trim bool
+		want []string
+	}{
+		{skip: false, want: []string{"", " ", "", "a", "", "b", "", ""}},
+		{skip: true, want: []string{" ", "", "a", "", "b", "", ""}},
+		{skip: true, trim: true, want: []string{"a", "", "b", "", ""}},
+	} {
+		in := Default()
+		in.SkipLeadingEmpty = tt.skip
+		in.TrimSpace = tt.trim
+		in.Stream = strings.NewReader(content)
+		if got := in.Args([]string{}); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("SkipLeadingEmpty=%t, TrimSpace=%t: Args() = %q, want %q",
+				tt.skip, tt.trim, got, tt.want)
+		}
+	}
+}
+
+func TestInputDrain(t *testing.T) {
+
+	in := Default()
+	in.Stream = strings.NewReader("one\ntwo\nthree\nfour\n")
+	for _, want := range []string{"one", "two"} {
+		if got, err := in.Line(); err != nil || got != want {
+			t.Fatalf("Line() = %q, %v, want %q, nil", got, err, want)
+		}
+	}
+	if n, err := in.Drain(); err != nil || n != int64(len("three\nfour\n")) {
+		t.Errorf("Drain() = %d, %v, want %d, nil", n, err, len("three\nfour\n"))
+	}
+	if n, err := in.Drain(); err != nil || n != 0 {
+		t.Errorf("Drain() again = %d, %v, want 0, nil", n, err)
+	}
+}
+
+func TestInputInitialBufSize(t *testing.T) {
+
+	content := strings.Repeat("short\n", 100) + strings.Repeat("x", 5000) + "\nend"
+	base := Default()
+	base.Stream = strings.NewReader(content)
+	want, err := base.ArgsErr([]string{})
+	if err != nil {
+		t.Fatalf("ArgsErr() error = %v", err)
+	}
+	for _, size := range []int{1, 16, 4096, 1 << 20} {
+		in := Default()
+		in.InitialBufSize = size
+		in.Stream = strings.NewReader(content)
+		got, err := in.ArgsErr([]string{})
+		if err != nil {
+			t.Fatalf("InitialBufSize=%d: ArgsErr() error = %v", size, err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("InitialBufSize=%d: ArgsErr() differs from default", size)
+		}
+	}
+
+	// The initial size does not raise the maximum token size.
+	in := Default()
+	in.InitialBufSize = 1 << 20
+	in.MaxTokenBytes = 100
+	in.Stream = strings.NewReader(content)
+	if _, err := in.ArgsErr([]string{}); !errors.Is(err, bufio.ErrTooLong) {
+		t.Errorf("ArgsErr() error = %v, want %v", err, bufio.ErrTooLong)
+	}
+}
+
+func BenchmarkInputInitialBufSize(b *testing.B) {
+
+	// Many short lines, with a few long enough to grow a small buffer.
+	content := strings.Repeat(strings.Repeat("token\n", 1000)+strings.Repeat("x", 32<<10)+"\n", 8)
+	for _, size := range []int{0, 64 << 10} {
+		b.Run(fmt.Sprintf("InitialBufSize=%d", size), func(b *testing.B) {
+			b.ReportAllocs()
+			in := Default()
+			in.InitialBufSize = size
+			for i := 0; i < b.N; i++ {
+				in.Stream = strings.NewReader(content)
+				_, _ = in.Count([]string{})
+			}
+		})
+	}
+}
+
+func TestInputArgsSet(t *testing.T) {
+
+	in := Default()
+	in.TrimSpace = true
+	in.Stream = strings.NewReader("b\na\n b \n\nc\na\n")
+	got := in.ArgsSet([]string{})
+	want := map[string]struct{}{"a": {}, "b": {}, "c": {}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ArgsSet() = %v, want %v", got, want)
+	}
+	if _, ok := got["b"]; !ok {
+		t.Errorf("ArgsSet()[%q] missing", "b")
+	}
+	if _, ok := got[""]; ok {
+		t.Errorf("ArgsSet()[%q] present, want missing", "")
+	}
+}
+
+func TestInputIdleTimeout(t *testing.T) {
+
+	start := runtime.NumGoroutine()
+	pr, pw := io.Pipe()
+	go func() {
+		defer pw.Close()
+		for _, s := range []string{"a\n", "b\n"} {
+			if _, err := io.WriteString(pw, s); err != nil {
+				return
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		// Pause beyond the timeout, and then write tokens that are never
+		// received, which must not block the scanning goroutine forever.
+		time.Sleep(400 * time.Millisecond)
+		_, _ = io.WriteString(pw, "c\nd\n")
+	}()
+
+	in := Default()
+	in.IdleTimeout = 200 * time.Millisecond
+	in.Stream = pr
+	got, err := in.ArgsErr([]string{})
+	if err != ErrIdleTimeout {
+		t.Errorf("ArgsErr() error = %v, want %v", err, ErrIdleTimeout)
+	}
+	if want := []string{"a", "b"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ArgsErr() = %q, want %q", got, want)
+	}
+
+	// EOF before the timeout is not an error.
+	in.Stream = strings.NewReader("c\n")
+	if got, err := in.ArgsErr([]string{}); err != nil || !reflect.DeepEqual(got, []string{"c"}) {
+		t.Errorf("ArgsErr(EOF) = %q, %v, want %q, nil", got, err, []string{"c"})
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for runtime.NumGoroutine() > start && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if n := runtime.NumGoroutine(); n > start {
+		t.Errorf("NumGoroutine() = %d after ArgsErr(), want %d", n, start)
+	}
+}
+
+func TestInputCursor(t *testing.T) {
+
+	in := Default()
+	in.Stream = &failReader{r: strings.NewReader("a\nb\n"), err: errors.New("fail")}
+	c := in.Cursor([]string{})
+	var got []string
+	for s, ok := c.Next(); ok; s, ok = c.Next() {
+		got = append(got, s)
+	}
+	if want := []string{"a", "b"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Cursor() tokens = %q, want %q", got, want)
+	}
+	if c.Err() == nil {
+		t.Errorf("Cursor().Err() = nil, want error")
+	}
+	if s, ok := c.Next(); ok {
+		t.Errorf("Next() after end = %q, true, want false", s)
+	}
+
+	// Stop early, leaving the remaining tokens unread.
+	c = in.Cursor([]string{"x", "y", "z"})
+	if s, ok := c.Next(); !ok || s != "x" {
+		t.Errorf("Next() = %q, %t, want %q, true", s, ok, "x")
+	}
+	c.Stop()
+	if s, ok := c.Next(); ok {
+		t.Errorf("Next() after Stop() = %q, true, want false", s)
+	}
+	if err := c.Err(); err != nil {
+		t.Errorf("Err() after Stop() = %v, want nil", err)
+	}
+	c.Stop()
+}
+
+func TestInputTrimCutset(t *testing.T) {
+
+	for _, tt := range []struct {
+		trim   bool
+		cutset string
+		want   []string
+	}{
+		{cutset: "", want: []string{"a,", ";b;", " ,c; ", ",;"}},
+		{cutset: ",;", want: []string{"a", "b", " ,c; ", ""}},
+		{trim: true, cutset: ",;", want: []string{"a", "b", "c", ""}},
+		{cutset: ",; ", want: []string{"a", "b", "c", ""}},
+	} {
+		in := Default()
+		in.TrimSpace = tt.trim
+		in.TrimCutset = tt.cutset
+		in.Stream = strings.NewReader("a,\n;b;\n ,c; \n,;\n")
+		if got := in.Args([]string{}); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("TrimSpace=%t, TrimCutset=%q: Args() = %q, want %q",
+				tt.trim, tt.cutset, got, tt.want)
+		}
+	}
+}
+
+func TestInputJoined(t *testing.T) {
+
+	path := writeTemp(t, "file.txt", "file\ncontent\n")
+	for _, tt := range []struct {
+		name string
+		args []string
+		want string
+	}{
+		{name: "args", args: []string{"a", "b", "c"}, want: "a b c"},
+		{name: "file", args: []string{path}, want: "file\ncontent\n"},
+		{name: "stream", args: []string{}, want: "stream\ncontent\n"},
+	} {
+		in := Default()
+		in.Stream = strings.NewReader("stream\ncontent\n")
+		got, err := in.Joined(tt.args)
+		if err != nil || got != tt.want {
+			t.Errorf("%s: Joined() = %q, %v, want %q, nil", tt.name, got, err, tt.want)
+		}
+	}
+}
+
+func TestInputProgress(t *testing.T) {
+
+	content := strings.Repeat("progress\n", 10000)
+	path := writeTemp(t, "big.txt", content)
+
+	type call struct{ n, total int64 }
+	var calls []call
+	in := Default()
+	in.Progress = func(n, total int64) { calls = append(calls, call{n, total}) }
+
+	var out bytes.Buffer
+	if n, err := in.Copy([]string{path}, &out); err != nil || n != int64(len(content)) {
+		t.Fatalf("Copy() = %d, %v, want %d, nil", n, err, len(content))
+	}
+	if len(calls) == 0 {
+		t.Fatalf("Copy(): Progress not called")
+	}
+	for i, c := range calls {
+		if c.total != int64(len(content)) {
+			t.Errorf("Progress call %d: total = %d, want %d", i, c.total, len(content))
+		}
+		if i > 0 && c.n < calls[i-1].n {
+			t.Errorf("Progress call %d: bytesRead = %d, want >= %d", i, c.n, calls[i-1].n)
+		}
+	}
+	if last := calls[len(calls)-1]; last.n != int64(len(content)) {
+		t.Errorf("Progress last bytesRead = %d, want %d", last.n, len(content))
+	}
+
+	calls = nil
+	in.Stream = iotest.HalfReader(strings.NewReader(content))
+	if _, err := in.ReadAll([]string{}); err != nil {
+		t.Fatalf("ReadAll() error = %v", err)
+	}
+	if last := calls[len(calls)-1]; last.n != int64(len(content)) || last.total != -1 {
+		t.Errorf("Progress(stream) last = %d, %d, want %d, -1", last.n, last.total, len(content))
+	}
+}
+
+func TestInputFieldsIndexed(t *testing.T) {
+
+	in := Default()
+	in.TrimSpace = true
+	in.Stream = strings.NewReader("a\n\n \nb\n\nc\n")
+	want := []IndexedField{{Index: 0, Value: "a"}, {Index: 3, Value: "b"}, {Index: 5, Value: "c"}}
+	if got := in.FieldsIndexed([]string{}); !reflect.DeepEqual(got, want) {
+		t.Errorf("FieldsIndexed() = %v, want %v", got, want)
+	}
+	want = []IndexedField{{Index: 1, Value: "x"}}
+	if got := in.FieldsIndexed([]string{"", "x", ""}); !reflect.DeepEqual(got, want) {
+		t.Errorf("FieldsIndexed(args) = %v, want %v", got, want)
+	}
+}
+
+func TestInputRequireInput(t *testing.T) {
+
+	for _, tt := range []struct {
+		require bool
+		args    []string
+		stdin   string
+		want    []string
+		err     error
+	}{
+		{require: false, args: []string{}, stdin: "", want: []string{}},
+		{require: true, args: []string{}, stdin: "", want: []string{}, err: ErrNoInput},
+		{require: true, args: []string{}, stdin: "# comment\n", want: []string{}, err: ErrNoInput},
+		{require: true, args: []string{}, stdin: "a\n", want: []string{"a"}},
+		{require: true, args: []string{}, stdin: "\n", want: []string{""}},
+		{require: true, args: []string{"x"}, stdin: "", want: []string{"x"}},
+	} {
+		in := Default()
+		in.RequireInput = tt.require
+		in.CommentPrefix = []byte("#")
+		in.Stream = strings.NewReader(tt.stdin)
+		got, err := in.ArgsErr(tt.args)
+		if !errors.Is(err, tt.err) {
+			t.Errorf("RequireInput=%t: ArgsErr(%q) error = %v, want %v",
+				tt.require, tt.stdin, err, tt.err)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("RequireInput=%t: ArgsErr(%q) = %q, want %q",
+				tt.require, tt.stdin, got, tt.want)
+		}
+	}
+
+	in := Default()
+	in.RequireInput = true
+	in.Stream = strings.NewReader("")
+	if _, err := in.ArgsContext(context.Background(), []string{}); err != ErrNoInput {
+		t.Errorf("ArgsContext() error = %v, want %v", err, ErrNoInput)
+	}
+}
+
+func TestInputStats(t *testing.T) {
+
+	const content = "one\r\ntwo\n\nthree"
+	join := func(a []string) string { return "[" + strings.Join(a, "|") + "]" }
+	for _, tt := range []struct {
+		args []string
+		join func([]string) string
+	}{
+		{args: []string{}},
+		{args: []string{"a", "bc", ""}},
+		{args: []string{"single"}},
+		{args: []string{"a", "b"}, join: join},
+	} {
+		args := tt.args
+		in := Default()
+		in.JoinFunc = tt.join
+		in.Stream = strings.NewReader(content)
+		tokens, bytes, err := in.Stats(args)
+		if err != nil {
+			t.Fatalf("Stats(%q) error = %v", args, err)
+		}
+		in.Stream = strings.NewReader(content)
+		count, _ := in.Count(args)
+		in.Stream = strings.NewReader(content)
+		b, _ := in.ReadAll(args)
+		if tokens != count || bytes != int64(len(b)) {
+			t.Errorf("Stats(%q) = %d, %d, want %d, %d", args, tokens, bytes, count, len(b))
+		}
+	}
+}
+
+func TestInputEscapeChar(t *testing.T) {
+
+	for _, tt := range []struct {
+		esc   byte
+		delim string
+		stdin string
+		want  []string
+	}{
+		{esc: 0, delim: ",", stdin: `a\,b,c`, want: []string{`a\`, "b", "c"}},
+		{esc: '\\', delim: ",", stdin: `a\,b,c`, want: []string{"a,b", "c"}},
+		{esc: '\\', delim: ",", stdin: `a\\,b\x,c\`, want: []string{`a\`, `b\x`, `c\`}},
+		{esc: '\\', delim: ",", stdin: `\,\,,`, want: []string{",,"}},
+		{esc: '\\', delim: "\n", stdin: "multi\\\nline\r\nnext\n", want: []string{"multi\nline", "next"}},
+		{esc: '\\', delim: "::", stdin: `a\::b::c\:d`, want: []string{"a::b", `c\:d`}},
+	} {
+		in := Default()
+		in.EscapeChar = tt.esc
+		in.ArgsDelim = []byte(tt.delim)
+		in.Stream = iotest.OneByteReader(strings.NewReader(tt.stdin))
+		if got := in.Args([]string{}); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("EscapeChar=%q: Args(%q) = %q, want %q", tt.esc, tt.stdin, got, tt.want)
+		}
+	}
+}
+
+func TestInputCollapseDelims(t *testing.T) {
+
+	for _, tt := range []struct {
+		collapse bool
+		keep     bool
+		delim    string
+		stdin    string
+		want     []string
+	}{
+		{delim: ",", stdin: "a,,b,,,c", want: []string{"a", "", "b", "", "", "c"}},
+		{collapse: true, delim: ",", stdin: "a,,b,,,c", want: []string{"a", "b", "c"}},
+		{collapse: true, delim: ",", stdin: ",,a,b,,", want: []string{"a", "b"}},
+		{collapse: true, keep: true, delim: ",", stdin: "a,,", want: []string{"a"}},
+		{collapse: true, delim: "\n", stdin: "a\r\n\r\n\nb\n", want: []string{"a", "b"}},
+		{collapse: true, delim: ",", stdin: ",,", want: []string{}},
+	} {
+		in := Default()
+		in.CollapseDelims = tt.collapse
+		in.KeepFinalEmpty = tt.keep
+		in.ArgsDelim = []byte(tt.delim)
+		in.Stream = iotest.HalfReader(strings.NewReader(tt.stdin))
+		if got := in.Args([]string{}); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("CollapseDelims=%t: Args(%q) = %q, want %q",
+				tt.collapse, tt.stdin, got, tt.want)
+		}
+	}
+}
+
+func TestInputSingle(t *testing.T) {
+
+	for _, tt := range []struct {
+		args  []string
+		stdin string
+		want  string
+		err   error
+	}{
+		{args: []string{}, stdin: "", err: ErrNoInput},
+		{args: []string{}, stdin: "\n\n", err: ErrNoInput},
+		{args: []string{}, stdin: "\nonly\n\n", want: "only"},
+		{args: []string{"arg"}, stdin: "ignored\n", want: "arg"},
+		{args: []string{}, stdin: "one\n\ntwo\nthree\n", err: ErrTooManyInputs},
+		{args: []string{"a", "b"}, err: ErrTooManyInputs},
+	} {
+		in := Default()
+		in.Stream = strings.NewReader(tt.stdin)
+		got, err := in.Single(tt.args)
+		if got != tt.want || !errors.Is(err, tt.err) {
+			t.Errorf("Single(%q, %q) = %q, %v, want %q, %v",
+				tt.args, tt.stdin, got, err, tt.want, tt.err)
+		}
+	}
+
+	in := Default()
+	in.Stream = strings.NewReader("one\ntwo\nthree\n")
+	if _, err := in.Single([]string{}); !strings.Contains(fmt.Sprint(err), `"two" follows "one"`) {
+		t.Errorf("Single() error = %v, want naming \"two\"", err)
+	}
+}
+
+func TestInputFirstOr(t *testing.T) {
+
+	for _, tt := range []struct {
+		trim  bool
+		args  []string
+		stdin string
+		want  string
+	}{
+		{args: []string{}, stdin: "", want: "def"},
+		{args: []string{}, stdin: "\n\n", want: "def"},
+		{args: []string{}, stdin: "  \n", want: "  "},
+		{trim: true, args: []string{}, stdin: " \t\n  \n", want: "def"},
+		{args: []string{}, stdin: "\nfirst\nsecond\n", want: "first"},
+		{args: []string{"", "arg"}, stdin: "stdin", want: "arg"},
+	} {
+		in := Default()
+		in.TrimSpace = tt.trim
+		in.Stream = strings.NewReader(tt.stdin)
+		if got := in.FirstOr(tt.args, "def"); got != tt.want {
+			t.Errorf("TrimSpace=%t: FirstOr(%q) = %q, want %q", tt.trim, tt.stdin, got, tt.want)
+		}
+	}
+
+	// Reading stops after the first token.
+	in := Default()
+	in.Stream = strings.NewReader("first\nsecond\n")
+	in.InitialBufSize = 1
+	_ = in.FirstOr([]string{}, "")
+	if rest, _ := io.ReadAll(in.Stream); len(rest) == 0 {
+		t.Errorf("FirstOr() consumed all of Stream")
+	}
+}
+
+func TestInputArgsChunks(t *testing.T) {
+
+	args := []string{"a", "b", "c", "d", "e", "f"}
+	for _, tt := range []struct {
+		args []string
+		size int
+		want [][]string
+	}{
+		{args: args, size: 2, want: [][]string{{"a", "b"}, {"c", "d"}, {"e", "f"}}},
+		{args: args, size: 4, want: [][]string{{"a", "b", "c", "d"}, {"e", "f"}}},
+		{args: args, size: 6, want: [][]string{args}},
+		{args: args, size: 10, want: [][]string{args}},
+		{args: args, size: 0, want: [][]string{args}},
+		{args: args, size: -1, want: [][]string{args}},
+		{args: []string{}, size: 2, want: [][]string{}},
+	} {
+		in := Default()
+		in.Stream = strings.NewReader("")
+		if got := in.ArgsChunks(tt.args, tt.size); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ArgsChunks(%q, %d) = %q, want %q", tt.args, tt.size, got, tt.want)
+		}
+	}
+}
+
+func TestInputIsEmpty(t *testing.T) {
+
+	const content = "a\n-\n\n \t\nnull\nb\n"
+	isEmpty := func(s string) bool {
+		return s == "-" || strings.TrimSpace(s) == ""
+	}
+	for _, tt := range []struct {
+		name    string
+		isEmpty func(string) bool
+		want    []string
+	}{
+		{name: "nil", want: []string{"a", "-", " \t", "null", "b"}},
+		{name: "custom", isEmpty: isEmpty, want: []string{"a", "null", "b"}},
+	} {
+		in := Default()
+		in.IsEmpty = tt.isEmpty
+		in.Stream = strings.NewReader(content)
+		if got := in.Fields([]string{}); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: Fields() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+
+	in := Default()
+	in.IsEmpty = isEmpty
+	if got := in.FirstOr([]string{"-", " ", "x"}, "def"); got != "x" {
+		t.Errorf("FirstOr() = %q, want %q", got, "x")
+	}
+}
+
+func TestInputReaderSeeker(t *testing.T) {
+
+	path := writeTemp(t, "seek.txt", "file content")
+	for _, tt := range []struct {
+		name   string
+		args   []string
+		stream io.Reader
+		want   string
+		typ    string
+	}{
+		{name: "file", args: []string{path}, want: "file content", typ: "*os.File"},
+		{name: "literal", args: []string{"a", "b"}, want: "a b", typ: "*strings.Reader"},
+		{name: "stream", args: []string{},
+			stream: iotest.HalfReader(strings.NewReader("streamed")), want: "streamed",
+			typ: "*bytes.Reader"},
+	} {
+		in := Default()
+		in.Stream = tt.stream
+		rs, err := in.ReaderSeeker(tt.args)
+		if err != nil {
+			t.Fatalf("%s: ReaderSeeker() error = %v", tt.name, err)
+		}
+		if typ := fmt.Sprintf("%T", rs); typ != tt.typ {
+			t.Errorf("%s: ReaderSeeker() = %s, want %s", tt.name, typ, tt.typ)
+		}
+		for pass := 1; pass <= 2; pass++ {
+			b, err := io.ReadAll(rs)
+			if err != nil || string(b) != tt.want {
+				t.Errorf("%s: pass %d: ReadAll() = %q, %v, want %q, nil",
+					tt.name, pass, b, err, tt.want)
+			}
+			if _, err := rs.Seek(0, io.SeekStart); err != nil {
+				t.Fatalf("%s: Seek() error = %v", tt.name, err)
+			}
+		}
+		if c, ok := rs.(io.Closer); ok {
+			_ = c.Close()
+		}
+	}
+}
+
+func TestInputWriteArgs(t *testing.T) {
+
+	tokens := []string{"alpha", "beta gamma", "", "delta"}
+	for _, tt := range []struct {
+		delim string
+		final bool
+		want  string
+	}{
+		{delim: "\n", want: "alpha\nbeta gamma\n\ndelta"},
+		{delim: "\n", final: true, want: "alpha\nbeta gamma\n\ndelta\n"},
+		{delim: ";;", final: true, want: "alpha;;beta gamma;;;;delta;;"},
+	} {
+		in := Default()
+		in.ArgsDelim = []byte(tt.delim)
+		in.WriteFinalDelim = tt.final
+		var b strings.Builder
+		n, err := in.WriteArgs(&b, tokens)
+		if err != nil || b.String() != tt.want || n != len(tt.want) {
+			t.Errorf("WriteArgs(%q) = %d, %v, wrote %q, want %d, nil, %q",
+				tt.delim, n, err, b.String(), len(tt.want), tt.want)
+		}
+		in.Stream = strings.NewReader(b.String())
+		if got := in.Args([]string{}); !reflect.DeepEqual(got, tokens) {
+			t.Errorf("Args(WriteArgs(%q)) = %q, want %q", tt.delim, got, tokens)
+		}
+	}
+}
+
+func TestInputNilStream(t *testing.T) {
+
+	var in Input
+	if got, err := in.ArgsErr([]string{}); err != nil || len(got) != 0 {
+		t.Errorf("ArgsErr() = %q, %v, want [], nil", got, err)
+	}
+	r, err := in.ReaderErr([]string{})
+	if err != nil {
+		t.Fatalf("ReaderErr() error = %v", err)
+	}
+	if b, err := io.ReadAll(r); err != nil || len(b) != 0 {
+		t.Errorf("ReadAll(ReaderErr()) = %q, %v, want \"\", nil", b, err)
+	}
+	if b, err := io.ReadAll(in.Reader([]string{})); err != nil || len(b) != 0 {
+		t.Errorf("ReadAll(Reader()) = %q, %v, want \"\", nil", b, err)
+	}
+	if _, err := in.Line(); err != io.EOF {
+		t.Errorf("Line() error = %v, want %v", err, io.EOF)
+	}
+}
+
+func TestInputDebug(t *testing.T) {
+
+	var b strings.Builder
+	in := Default()
+	in.Debug = &b
+	in.Stream = strings.NewReader("a\r\nb\r\n")
+	if got, want := in.Args([]string{}), []string{"a", "b"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Args() = %q, want %q", got, want)
+	}
+	want := `clin: delimiter "\n" at offset 2
+clin: trimmed "\r" at offset 1
+clin: token "a"
+clin: delimiter "\n" at offset 2
+clin: trimmed "\r" at offset 1
+clin: token "b"
+clin: skipped final empty token
+`
+	if b.String() != want {
+		t.Errorf("Debug = %q, want %q", b.String(), want)
+	}
+}
+
+func TestInputRecords(t *testing.T) {
+
+	for _, tt := range []struct {
+		delim string
+		trim  bool
+		stdin string
+		want  [][]string
+	}{
+		{stdin: "a\nb\n\nc\n\nd\ne\nf\n", want: [][]string{{"a", "b"}, {"c"}, {"d", "e", "f"}}},
+		{stdin: "a\r\nb\r\n\nc\n\n", want: [][]string{{"a", "b"}, {"c"}}},
+		{stdin: "a\r\nb\r\n\r\nc\r\nd\r\n", want: [][]string{{"a", "b"}, {"c", "d"}}},
+		{trim: true, stdin: " a \n\n b\n", want: [][]string{{"a"}, {"b"}}},
+		{delim: "--\n", stdin: "a\nb\n--\nc\n", want: [][]string{{"a", "b"}, {"c"}}},
+		{stdin: "", want: [][]string{}},
+	} {
+		in := Default()
+		in.RecordDelim = []byte(tt.delim)
+		in.TrimSpace = tt.trim
+		in.Stream = strings.NewReader(tt.stdin)
+		got, err := in.Records([]string{})
+		if err != nil || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Records(%q) = %q, %v, want %q, nil", tt.stdin, got, err, tt.want)
+		}
+	}
+
+	in := Default()
+	got, err := in.Records([]string{"a\nb", "c"})
+	if want := [][]string{{"a", "b"}, {"c"}}; err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("Records() = %q, %v, want %q, nil", got, err, want)
+	}
+}
@@ -0,0 +1,100 @@
+//go:build unix
+
+package clin
+
+import (
+	"errors"
+	"io"
+	"os"
+	"reflect"
+	"runtime"
+	"strconv"
+	"testing"
+)
+
+func TestInputFDArg(t *testing.T) {
+
+	pr, pw, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pr.Close()
+	fd := int(pr.Fd())
+	arg := strconv.Itoa(fd)
+	go func() {
+		_, _ = io.WriteString(pw, "a\nb\n")
+		_ = pw.Close()
+	}()
+
+	in := Default()
+	if got := in.Source([]string{arg}); got != SourceArgs {
+		t.Errorf("FDArg=false: Source(%q) = %v, want %v", arg, got, SourceArgs)
+	}
+	in.FDArg = true
+	if got := in.Source([]string{arg}); got != SourceFile {
+		t.Errorf("FDArg=true: Source(%q) = %v, want %v", arg, got, SourceFile)
+	}
+	rc, err := in.ReaderCloser([]string{arg})
+	if err != nil {
+		t.Fatalf("ReaderCloser(%q) error = %v", arg, err)
+	}
+	f, ok := rc.(readCloser).Reader.(*os.File)
+	if !ok || int(f.Fd()) == fd {
+		t.Fatalf("ReaderCloser(%q) = %T, want *os.File with a duplicate of descriptor %d",
+			arg, rc, fd)
+	}
+	sub := in
+	sub.Stream = rc
+	if got, want := sub.Args([]string{}), []string{"a", "b"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ReaderCloser(%q) tokens = %q, want %q", arg, got, want)
+	}
+	if err := rc.Close(); err != nil {
+		t.Errorf("Close() error = %v", err)
+	}
+	if _, err := f.Read(make([]byte, 1)); !errors.Is(err, os.ErrClosed) {
+		t.Errorf("Read() after Close() error = %v, want %v", err, os.ErrClosed)
+	}
+	// The caller's descriptor is still open.
+	if _, err := pr.Read(make([]byte, 1)); err != io.EOF {
+		t.Errorf("Read(%q) after Close() error = %v, want %v", arg, err, io.EOF)
+	}
+
+	for _, arg := range []string{"-1", "65536", "99999999999999999999", "+3", "65535"} {
+		b, err := in.ReadAll([]string{arg})
+		if err != nil || string(b) != arg {
+			t.Errorf("ReadAll(%q) = %q, %v, want %q, nil", arg, b, err, arg)
+		}
+		if got := in.Source([]string{arg}); got != SourceArgs {
+			t.Errorf("Source(%q) = %v, want %v", arg, got, SourceArgs)
+		}
+	}
+}
+
+func TestInputFDArgGC(t *testing.T) {
+
+	pr, pw, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pr.Close()
+	defer pw.Close()
+	if _, err := io.WriteString(pw, "a\nb\n"); err != nil {
+		t.Fatal(err)
+	}
+
+	in := Default()
+	in.FDArg = true
+	arg := strconv.Itoa(int(pr.Fd()))
+	b := make([]byte, 2)
+	if _, err := io.ReadFull(in.Reader([]string{arg}), b); err != nil || string(b) != "a\n" {
+		t.Fatalf("Reader(%q) = %q, %v, want %q, nil", arg, b, err, "a\n")
+	}
+	// Dropping the reader returned by Reader must not close the caller's
+	// descriptor once it is garbage collected.
+	for i := 0; i < 3; i++ {
+		runtime.GC()
+	}
+	if _, err := io.ReadFull(pr, b); err != nil || string(b) != "b\n" {
+		t.Errorf("Read(%q) after GC = %q, %v, want %q, nil", arg, b, err, "b\n")
+	}
+}
@@ -0,0 +1,96 @@
+package clin
+
+import (
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"io"
+	"os"
+)
+
+// gzipMagic identifies the beginning of a gzip stream (RFC 1952).
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// bzip2Magic identifies the beginning of a bzip2 stream. It is followed by a
+// digit '1' through '9' that indicates the block size.
+var bzip2Magic = []byte("BZh")
+
+// sniffLen is the number of leading bytes of a file examined by decompress to
+// verify its compression format.
+const sniffLen = 512
+
+// compression describes a compression format recognized by decompress.
+type compression struct {
+	// magic reports whether b begins with the magic bytes of the format.
+	magic func(b []byte) bool
+	// open returns a decompressor over r.
+	open func(r io.Reader) (io.ReadCloser, error)
+}
+
+// compressions are the formats recognized by decompress, in order of
+// precedence.
+var compressions = []compression{
+	{
+		magic: func(b []byte) bool { return bytes.HasPrefix(b, gzipMagic) },
+		open:  func(r io.Reader) (io.ReadCloser, error) { return gzip.NewReader(r) },
+	},
+	{
+		magic: func(b []byte) bool {
+			n := len(bzip2Magic)
+			return bytes.HasPrefix(b, bzip2Magic) &&
+				len(b) > n && '1' <= b[n] && b[n] <= '9'
+		},
+		open: func(r io.Reader) (io.ReadCloser, error) {
+			return io.NopCloser(bzip2.NewReader(r)), nil
+		},
+	},
+	{
+		// RFC 1950: compression method 8 (deflate) with a window size no larger
+		// than 32 KiB, and a header checksum that is a multiple of 31.
+		magic: func(b []byte) bool {
+			return len(b) >= 2 && b[0]&0x0f == 8 && b[0]>>4 <= 7 &&
+				(uint16(b[0])<<8|uint16(b[1]))%31 == 0
+		},
+		open: func(r io.Reader) (io.ReadCloser, error) { return zlib.NewReader(r) },
+	},
+}
+
+// valid reports whether the leading bytes b of a file can be decompressed
+// with c. Since b may be only a prefix of the file, reaching the end of b is
+// not an error.
+func (c compression) valid(b []byte) bool {
+	if !c.magic(b) {
+		return false
+	}
+	z, err := c.open(bytes.NewReader(b))
+	if err != nil {
+		return false
+	}
+	_, err = z.Read(make([]byte, 1))
+	return err == nil || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// decompress returns a reader over the decompressed content of f if it begins
+// with the magic bytes of a supported compression format (gzip, bzip2, or
+// zlib), along with an io.Closer that closes both the decompressor and f.
+// If the format is not recognized or its header is invalid, f is returned
+// unmodified, positioned at its beginning.
+func decompress(f *os.File) (io.Reader, io.Closer) {
+	br := bufio.NewReader(f)
+	b, _ := br.Peek(sniffLen)
+	for _, c := range compressions {
+		if c.valid(b) {
+			if z, err := c.open(br); err == nil {
+				return z, closers{z, f}
+			}
+		}
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		// Cannot rewind (e.g., a named pipe): continue with what was buffered.
+		return br, f
+	}
+	return f, f
+}
@@ -0,0 +1,138 @@
+package clin
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"io"
+	"os"
+	"testing"
+)
+
+// gzipped returns s compressed with gzip.
+func gzipped(t *testing.T, s string) string {
+	t.Helper()
+	var b bytes.Buffer
+	z := gzip.NewWriter(&b)
+	if _, err := z.Write([]byte(s)); err != nil {
+		t.Fatal(err)
+	}
+	if err := z.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return b.String()
+}
+
+// zlibbed returns s compressed with zlib.
+func zlibbed(t *testing.T, s string) string {
+	t.Helper()
+	var b bytes.Buffer
+	z := zlib.NewWriter(&b)
+	if _, err := z.Write([]byte(s)); err != nil {
+		t.Fatal(err)
+	}
+	if err := z.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return b.String()
+}
+
+// bzipped is "compressed\ncontent\n" compressed with bzip2 -9, which has no
+// encoder in the standard library.
+const bzipped = "BZh91AY&SY\x87\xc6\xe9\xda\x00\x00\x01\x41\x80\x00\x10\x0e" +
+	"\x03\xdc\x00\x20\x00\x21\xa9\xa6\xd0\x8f\xd4\x20\x1a\x69\xa0\x9e" +
+	"\xa6\x33\xee\x31\x78\xca\x16\x8b\xb9\x22\x9c\x28\x48\x43\xe3\x74" +
+	"\xed\x00"
+
+func TestInputAutoDecompress(t *testing.T) {
+
+	const content = "compressed\ncontent\n"
+	gz := gzipped(t, content)
+	zl := zlibbed(t, content)
+
+	for _, tt := range []struct {
+		name string
+		file string
+		auto bool
+		want string
+	}{
+		{name: "x.gz", file: gz, auto: false, want: gz},
+		{name: "x.gz", file: gz, auto: true, want: content},
+		{name: "x", file: gz, auto: true, want: content},
+		{name: "plain.gz", file: content, auto: true, want: content},
+		{name: "bad.gz", file: "\x1f\x8bgarbage", auto: true, want: "\x1f\x8bgarbage"},
+		{name: "x.bz2", file: bzipped, auto: false, want: bzipped},
+		{name: "x.bz2", file: bzipped, auto: true, want: content},
+		{name: "bad.bz2", file: "BZh9garbage", auto: true, want: "BZh9garbage"},
+		{name: "x.zz", file: zl, auto: false, want: zl},
+		{name: "x.zz", file: zl, auto: true, want: content},
+		{name: "text.zz", file: "x^ not zlib", auto: true, want: "x^ not zlib"},
+	} {
+		path := writeTemp(t, tt.name, tt.file)
+
+		in := Default()
+		in.AutoDecompress = tt.auto
+		b, err := io.ReadAll(in.Reader([]string{path}))
+		if err != nil {
+			t.Fatalf("%s: ReadAll() error = %v", tt.name, err)
+		}
+		if got := string(b); got != tt.want {
+			t.Errorf("%s: AutoDecompress=%t: Reader() = %q, want %q",
+				tt.name, tt.auto, got, tt.want)
+		}
+	}
+}
+
+func TestInputAutoDecompressClose(t *testing.T) {
+
+	path := writeTemp(t, "x.gz", gzipped(t, "content"))
+
+	in := Default()
+	in.AutoDecompress = true
+	rc, err := in.ReaderCloser([]string{path})
+	if err != nil {
+		t.Fatalf("ReaderCloser() error = %v", err)
+	}
+	c, ok := rc.(readCloser)
+	if !ok {
+		t.Fatalf("ReaderCloser() = %T, want readCloser", rc)
+	}
+	if err := rc.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	f := c.Closer.(closers)[1].(*os.File)
+	if _, err := f.Read(make([]byte, 1)); !errors.Is(err, os.ErrClosed) {
+		t.Errorf("Read() after Close() error = %v, want %v", err, os.ErrClosed)
+	}
+}
+
+func TestInputAutoDecompressFormatsClose(t *testing.T) {
+
+	for _, tt := range []struct {
+		name string
+		file string
+	}{
+		{name: "x.bz2", file: bzipped},
+		{name: "x.zz", file: zlibbed(t, "content")},
+	} {
+		in := Default()
+		in.AutoDecompress = true
+		rc, err := in.ReaderCloser([]string{writeTemp(t, tt.name, tt.file)})
+		if err != nil {
+			t.Fatalf("%s: ReaderCloser() error = %v", tt.name, err)
+		}
+		c, ok := rc.(readCloser)
+		if !ok {
+			t.Fatalf("%s: ReaderCloser() = %T, want readCloser", tt.name, rc)
+		}
+		if err := rc.Close(); err != nil {
+			t.Fatalf("%s: Close() error = %v", tt.name, err)
+		}
+		f := c.Closer.(closers)[1].(*os.File)
+		if _, err := f.Read(make([]byte, 1)); !errors.Is(err, os.ErrClosed) {
+			t.Errorf("%s: Read() after Close() error = %v, want %v",
+				tt.name, err, os.ErrClosed)
+		}
+	}
+}
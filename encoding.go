@@ -0,0 +1,166 @@
+package clin
+
+import (
+	"bufio"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"io"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Encoding identifies the character encoding of Stream.
+type Encoding int
+
+// Constants defining each supported Encoding.
+const (
+	// UTF8 is the default encoding, and Stream is read without conversion.
+	UTF8 Encoding = iota
+	// UTF16 decodes Stream as UTF-16, whose byte order is determined by a
+	// leading byte order mark (BOM), if present, which is then discarded.
+	// In the absence of a BOM, little-endian (the Windows convention) is used.
+	UTF16
+	// UTF16LE decodes Stream as little-endian UTF-16.
+	UTF16LE
+	// UTF16BE decodes Stream as big-endian UTF-16.
+	UTF16BE
+)
+
+// decoder returns a reader that converts r from the receiver Encoding to
+// UTF-8.
+func (e Encoding) decoder(r io.Reader) io.Reader {
+	switch e {
+	case UTF16:
+		return &utf16Reader{r: bufio.NewReader(r), pend: -1}
+	case UTF16LE:
+		return &utf16Reader{r: bufio.NewReader(r), order: binary.LittleEndian, pend: -1}
+	case UTF16BE:
+		return &utf16Reader{r: bufio.NewReader(r), order: binary.BigEndian, pend: -1}
+	}
+	return r
+}
+
+// utf16Reader decodes UTF-16 from an underlying reader, returning UTF-8.
+// Invalid or incomplete code units are replaced with utf8.RuneError.
+type utf16Reader struct {
+	r     *bufio.Reader
+	order binary.ByteOrder // nil until detected from the BOM
+	pend  rune             // code unit read ahead, or -1 if none
+	out   []byte           // decoded bytes not yet returned by Read
+	err   error
+}
+
+func (u *utf16Reader) Read(p []byte) (int, error) {
+	if u.order == nil {
+		u.order = binary.LittleEndian
+		switch b, _ := u.r.Peek(2); string(b) {
+		case "\xFF\xFE":
+			_, _ = u.r.Discard(2)
+		case "\xFE\xFF":
+			u.order = binary.BigEndian
+			_, _ = u.r.Discard(2)
+		}
+	}
+	for len(u.out) == 0 {
+		if u.err != nil {
+			return 0, u.err
+		}
+		u.decode()
+	}
+	n := copy(p, u.out)
+	u.out = u.out[n:]
+	return n, nil
+}
+
+// decode reads a single rune from the underlying reader and appends its UTF-8
+// encoding to out. Any error is stored in err.
+func (u *utf16Reader) decode() {
+	r, err := u.unit()
+	if err != nil {
+		u.err = err
+		return
+	}
+	if utf16.IsSurrogate(r) {
+		s, err := u.unit()
+		switch {
+		case err != nil:
+			u.err = err
+			r = utf8.RuneError
+		case utf16.DecodeRune(r, s) == utf8.RuneError:
+			// Unpaired surrogate: keep the second code unit for next time.
+			u.pend = s
+			r = utf8.RuneError
+		default:
+			r = utf16.DecodeRune(r, s)
+		}
+	}
+	u.out = utf8.AppendRune(u.out, r)
+}
+
+// unit returns the next UTF-16 code unit.
+func (u *utf16Reader) unit() (rune, error) {
+	if u.pend >= 0 {
+		r := u.pend
+		u.pend = -1
+		return r, nil
+	}
+	var b [2]byte
+	if _, err := io.ReadFull(u.r, b[:]); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			// A trailing odd byte: the next read returns io.EOF.
+			return utf8.RuneError, nil
+		}
+		return 0, err
+	}
+	return rune(u.order.Uint16(b[:])), nil
+}
+
+// Decoding identifies a binary-to-text encoding of the content read by Reader.
+type Decoding int
+
+// Constants defining each supported Decoding.
+const (
+	// DecodeNone is the default, and content is read without conversion.
+	DecodeNone Decoding = iota
+	// DecodeBase64 decodes standard base64 (RFC 4648), with padding. Newlines
+	// in the encoded content are ignored.
+	DecodeBase64
+	// DecodeHex decodes hexadecimal, with two digits per byte. ASCII white
+	// space in the encoded content is ignored.
+	DecodeHex
+)
+
+// decoder returns a reader that decodes r according to the receiver Decoding.
+func (d Decoding) decoder(r io.Reader) io.Reader {
+	switch d {
+	case DecodeBase64:
+		return base64.NewDecoder(base64.StdEncoding, r)
+	case DecodeHex:
+		return hex.NewDecoder(spaceFilter{r})
+	}
+	return r
+}
+
+// spaceFilter removes ASCII white space from the content of an io.Reader.
+type spaceFilter struct {
+	r io.Reader
+}
+
+func (f spaceFilter) Read(p []byte) (int, error) {
+	for {
+		n, err := f.r.Read(p)
+		k := 0
+		for _, c := range p[:n] {
+			switch c {
+			case ' ', '\t', '\n', '\v', '\f', '\r':
+			default:
+				p[k] = c
+				k++
+			}
+		}
+		if k > 0 || err != nil {
+			return k, err
+		}
+	}
+}
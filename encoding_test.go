@@ -0,0 +1,114 @@
+package clin
+
+import (
+	"encoding/binary"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"unicode/utf16"
+)
+
+// encodeUTF16 returns s encoded as UTF-16 in the given byte order, prefixed
+// with a byte order mark if bom is true.
+func encodeUTF16(s string, order binary.ByteOrder, bom bool) string {
+	u := utf16.Encode([]rune(s))
+	if bom {
+		u = append([]uint16{0xFEFF}, u...)
+	}
+	b := make([]byte, 2*len(u))
+	for i, c := range u {
+		order.PutUint16(b[2*i:], c)
+	}
+	return string(b)
+}
+
+func TestInputEncoding(t *testing.T) {
+
+	const text = "ascii\r\nüñí\n\U0001F600 smile\n"
+	want := []string{"ascii", "üñí", "\U0001F600 smile"}
+
+	for _, tt := range []struct {
+		name  string
+		enc   Encoding
+		stdin string
+	}{
+		{"LE", UTF16LE, encodeUTF16(text, binary.LittleEndian, false)},
+		{"BE", UTF16BE, encodeUTF16(text, binary.BigEndian, false)},
+		{"LE+BOM", UTF16, encodeUTF16(text, binary.LittleEndian, true)},
+		{"BE+BOM", UTF16, encodeUTF16(text, binary.BigEndian, true)},
+		{"LE/noBOM", UTF16, encodeUTF16(text, binary.LittleEndian, false)},
+	} {
+		in := Default()
+		in.Encoding = tt.enc
+		in.Stream = iotest.OneByteReader(strings.NewReader(tt.stdin))
+		if got := in.Args([]string{}); !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: Args() = %q, want %q", tt.name, got, want)
+		}
+	}
+
+	// Unpaired surrogates and a trailing odd byte decode to U+FFFD.
+	in := Default()
+	in.Encoding = UTF16BE
+	in.Stream = strings.NewReader("\xD8\x00\x00a\x00b\x00")
+	got, want := in.Args([]string{}), []string{"\uFFFDab\uFFFD"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("invalid: Args() = %q, want %q", got, want)
+	}
+}
+
+func TestInputDecode(t *testing.T) {
+
+	path := writeTemp(t, "input.b64", "aGVsbG8s\nIHdvcmxk\n")
+
+	for _, tt := range []struct {
+		name   string
+		decode Decoding
+		args   []string
+		stdin  string
+		want   string
+	}{
+		{"none", DecodeNone, []string{"aGVsbG8="}, "", "aGVsbG8="},
+		{"base64", DecodeBase64, []string{"aGVsbG8sIHdvcmxk"}, "", "hello, world"},
+		{"base64-file", DecodeBase64, []string{path}, "", "hello, world"},
+		{"base64-stdin", DecodeBase64, []string{}, "aGVsbG8=\n", "hello"},
+		{"hex", DecodeHex, []string{"68656c6c6f"}, "", "hello"},
+		{"hex-joined", DecodeHex, []string{"6865", "6c6c6f"}, "", "hello"},
+		{"hex-stdin", DecodeHex, []string{}, "68 65 6C\r\n6C 6F\n", "hello"},
+	} {
+		in := Default()
+		in.Decode = tt.decode
+		in.Stream = strings.NewReader(tt.stdin)
+		r, err := in.ReaderErr(tt.args)
+		if err != nil {
+			t.Fatalf("%s: ReaderErr() error = %v", tt.name, err)
+		}
+		b, err := io.ReadAll(r)
+		if err != nil {
+			t.Fatalf("%s: ReadAll() error = %v", tt.name, err)
+		}
+		if got := string(b); got != tt.want {
+			t.Errorf("%s: Reader() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+
+	for _, tt := range []struct {
+		decode Decoding
+		arg    string
+	}{
+		{DecodeBase64, "not base64!"},
+		{DecodeHex, "0xZZ"},
+		{DecodeHex, "abc"},
+	} {
+		in := Default()
+		in.Decode = tt.decode
+		r, err := in.ReaderErr([]string{tt.arg})
+		if err != nil {
+			t.Fatalf("ReaderErr(%q) error = %v", tt.arg, err)
+		}
+		if _, err := io.ReadAll(r); err == nil {
+			t.Errorf("ReadAll(%q) error = nil, want decoding error", tt.arg)
+		}
+	}
+}
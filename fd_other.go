@@ -0,0 +1,9 @@
+//go:build !(linux || darwin || dragonfly || freebsd || netbsd || openbsd)
+
+package clin
+
+import "errors"
+
+// dupFD returns errors.ErrUnsupported on this platform, where FDArg is not
+// supported.
+func dupFD(int) (int, error) { return -1, errors.ErrUnsupported }
@@ -0,0 +1,34 @@
+package clin
+
+import (
+	"errors"
+	"flag"
+)
+
+// ErrNotParsed is returned by BindFlags when the given flag.FlagSet has not
+// been parsed.
+var ErrNotParsed = errors.New("clin: flag set not parsed")
+
+// BindFlags returns the positional arguments of the given flag.FlagSet, or the
+// tokens read from Stream if there are none.
+//
+// See Input.BindFlags for details.
+func BindFlags(flags *flag.FlagSet) ([]string, error) { return input.BindFlags(flags) }
+
+// BindFlags returns the tokens returned by ArgsErr for the positional
+// arguments remaining after flags has been parsed (i.e., flags.Args()).
+//
+// Input is therefore resolved in the following order of precedence:
+//  1. The positional arguments given on the command line, if any, following
+//     the same rules as Args (e.g., a single file path argument is returned
+//     as-is).
+//  2. Otherwise, the tokens read from Stream.
+//
+// BindFlags must be called after flags.Parse, and returns ErrNotParsed
+// otherwise, so that flags are never mistaken for positional arguments.
+func (in *Input) BindFlags(flags *flag.FlagSet) ([]string, error) {
+	if !flags.Parsed() {
+		return nil, ErrNotParsed
+	}
+	return in.ArgsErr(flags.Args())
+}
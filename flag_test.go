@@ -0,0 +1,45 @@
+package clin
+
+import (
+	"flag"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestInputBindFlags(t *testing.T) {
+
+	for _, tt := range []struct {
+		args []string
+		want []string
+		v    bool
+	}{
+		{args: []string{"-v", "a", "b"}, want: []string{"a", "b"}, v: true},
+		{args: []string{"a", "-v"}, want: []string{"a", "-v"}},
+		{args: []string{"-v"}, want: []string{"x", "y"}, v: true},
+		{args: []string{}, want: []string{"x", "y"}},
+		{args: []string{"--", "-v"}, want: []string{"-v"}},
+	} {
+		flags := flag.NewFlagSet("test", flag.ContinueOnError)
+		flags.SetOutput(io.Discard)
+		v := flags.Bool("v", false, "verbose")
+
+		in := Default()
+		in.Stream = strings.NewReader("x\ny\n")
+		if _, err := in.BindFlags(flags); err != ErrNotParsed {
+			t.Errorf("BindFlags() before Parse error = %v, want %v", err, ErrNotParsed)
+		}
+		if err := flags.Parse(tt.args); err != nil {
+			t.Fatalf("Parse(%q) error = %v", tt.args, err)
+		}
+		got, err := in.BindFlags(flags)
+		if err != nil {
+			t.Fatalf("BindFlags(%q) error = %v", tt.args, err)
+		}
+		if !reflect.DeepEqual(got, tt.want) || *v != tt.v {
+			t.Errorf("BindFlags(%q) = %q (-v=%t), want %q (-v=%t)",
+				tt.args, got, *v, tt.want, tt.v)
+		}
+	}
+}
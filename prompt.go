@@ -0,0 +1,266 @@
+package clin
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// ErrInvalidAnswer is returned by interactive prompts (e.g., Confirm) when no
+// acceptable answer is read after maxAttempts attempts.
+var ErrInvalidAnswer = errors.New("clin: invalid answer")
+
+// ErrMissingSentinel is returned by ReadUntil when Stream is exhausted before
+// the sentinel is read.
+var ErrMissingSentinel = errors.New("clin: sentinel not found")
+
+// maxAttempts is the number of times an interactive prompt asks for an answer
+// before giving up with ErrInvalidAnswer.
+const maxAttempts = 3
+
+// Line returns the next line read from Stream.
+//
+// See Input.Line for details.
+func Line() (string, error) { return input.Line() }
+
+// Password writes label to Out and returns the next line read from Stream,
+// without echo.
+//
+// See Input.Password for details.
+func Password(label string) (string, error) { return input.Password(label) }
+
+// Confirm asks a yes/no question and reports the answer.
+//
+// See Input.Confirm for details.
+func Confirm(label string, def bool) (bool, error) { return input.Confirm(label, def) }
+
+// Select asks to choose one of the given options, and returns the choice.
+//
+// See Input.Select for details.
+func Select(label string, options []string) (int, string, error) {
+	return input.Select(label, options)
+}
+
+// Prompt writes label to Out and returns the next line read from Stream.
+//
+// See Input.Prompt for details.
+func Prompt(label string) (string, error) { return input.Prompt(label) }
+
+// Prompt writes label to Out, and then reads and returns a single line from
+// Stream using Line.
+func (in *Input) Prompt(label string) (string, error) {
+	if in.Out != nil {
+		if _, err := io.WriteString(in.Out, label); err != nil {
+			return "", err
+		}
+	}
+	return in.Line()
+}
+
+// Confirm writes label to Out, followed by a hint of the accepted answers
+// (" [y/N] " or " [Y/n] ", with def capitalized), and then reads a line from
+// Stream using Line.
+// The answers "y" and "yes" return true, and "n" and "no" return false,
+// ignoring case and surrounding white space. An empty line returns def.
+//
+// Any other answer repeats the prompt. After maxAttempts invalid answers,
+// returns def and an error wrapping ErrInvalidAnswer. If Stream is exhausted,
+// returns def and io.EOF.
+func (in *Input) Confirm(label string, def bool) (bool, error) {
+	hint := " [y/N] "
+	if def {
+		hint = " [Y/n] "
+	}
+	var ans string
+	for i := 0; i < maxAttempts; i++ {
+		line, err := in.Prompt(label + hint)
+		if err != nil {
+			return def, err
+		}
+		switch ans = strings.ToLower(strings.TrimSpace(line)); ans {
+		case "":
+			return def, nil
+		case "y", "yes":
+			return true, nil
+		case "n", "no":
+			return false, nil
+		}
+	}
+	return def, fmt.Errorf("%w: %q", ErrInvalidAnswer, ans)
+}
+
+// Select writes each element of options to Out on its own line, numbered from
+// 1, followed by label, and then reads a line from Stream using Line.
+// The line must contain the number of one of the options, optionally
+// surrounded by white space. Returns the index of the chosen option in options
+// (i.e., one less than its number) along with the option itself.
+//
+// Any other answer repeats label. After maxAttempts invalid answers, returns
+// an error wrapping ErrInvalidAnswer. If Stream is exhausted, returns io.EOF.
+// If options is empty, returns ErrInvalidAnswer without reading from Stream.
+func (in *Input) Select(label string, options []string) (int, string, error) {
+	if len(options) == 0 {
+		return -1, "", fmt.Errorf("%w: no options", ErrInvalidAnswer)
+	}
+	if in.Out != nil {
+		for i, opt := range options {
+			if _, err := fmt.Fprintf(in.Out, "%d) %s\n", i+1, opt); err != nil {
+				return -1, "", err
+			}
+		}
+	}
+	var ans string
+	for i := 0; i < maxAttempts; i++ {
+		line, err := in.Prompt(label)
+		if err != nil {
+			return -1, "", err
+		}
+		ans = strings.TrimSpace(line)
+		if n, err := strconv.Atoi(ans); err == nil && 1 <= n && n <= len(options) {
+			return n - 1, options[n-1], nil
+		}
+	}
+	return -1, "", fmt.Errorf("%w: %q", ErrInvalidAnswer, ans)
+}
+
+// Password writes label to Out, and then reads and returns a single line from
+// Stream using Line, without echoing the line to the terminal.
+//
+// Echo is disabled only if Stream is an *os.File that refers to a terminal on
+// a Unix-like system, and the previous terminal state is restored before
+// Password returns, even on error. Since the newline entered by the user is
+// not echoed either, Password writes a newline to Out in its place.
+// Otherwise, for example if Stream is a pipe, the line is read as-is.
+//
+// The terminal state is not restored if the process is terminated while
+// Password is reading.
+func (in *Input) Password(label string) (line string, err error) {
+	if in.Out != nil {
+		if _, err := io.WriteString(in.Out, label); err != nil {
+			return "", err
+		}
+	}
+	restore, err := noEcho(in.Stream)
+	if err != nil {
+		return "", err
+	}
+	if restore == nil {
+		return in.Line()
+	}
+	defer func() {
+		if rerr := restore(); err == nil {
+			err = rerr
+		}
+	}()
+	line, err = in.Line()
+	if in.Out != nil {
+		_, _ = io.WriteString(in.Out, "\n")
+	}
+	return line, err
+}
+
+// ReadUntil reads lines from Stream using Line until a line equal to sentinel
+// is read, in the manner of a shell here-document, and returns the lines that
+// precede it. No input following the sentinel is consumed.
+//
+// The per-token options configured in Input (e.g., TrimSpace and
+// CommentPrefix) are applied to each line before it is compared with
+// sentinel.
+//
+// If Stream is exhausted before sentinel is read, returns the lines read along
+// with an error wrapping ErrMissingSentinel.
+func (in *Input) ReadUntil(sentinel string) ([]string, error) {
+	a := []string{}
+	for {
+		line, err := in.Line()
+		if err == io.EOF {
+			return a, fmt.Errorf("%w: %q", ErrMissingSentinel, sentinel)
+		}
+		if err != nil {
+			return a, err
+		}
+		s, ok := in.token(line)
+		switch {
+		case !ok:
+			continue
+		case s == sentinel:
+			return a, nil
+		}
+		a = append(a, s)
+	}
+}
+
+// Line reads and returns a single line from Stream, without its trailing
+// delimiter.
+// Lines are delimited by ArgsDelim, with the same CR+LF handling used by Args.
+//
+// If Stream is exhausted before any data is read, returns io.EOF. An empty
+// line returns an empty string and nil error. If Stream is exhausted after
+// reading a partial line, the partial line is returned with nil error.
+//
+// Unlike Args, Stream is read one byte at a time, so that no input following
+// the line is consumed. Subsequent calls can therefore read from the same
+// Stream.
+func (in *Input) Line() (string, error) {
+	var buf []byte
+	var b [1]byte
+	tail := in.maxDelimLen()
+	for {
+		n, err := in.input().Read(b[:])
+		if n > 0 {
+			buf = append(buf, b[0])
+			// Only a delimiter beginning near the end of buf can end the line
+			// now, so avoid rescanning all of buf for every byte read.
+			if !in.delimNear(buf, tail) {
+				continue
+			}
+			if adv, tok, _ := in.scanArgs(buf, false); tok != nil {
+				return string(tok), nil
+			} else if adv > 0 {
+				// Delimiters skipped by CollapseDelims.
+				buf = buf[:copy(buf, buf[adv:])]
+			}
+		}
+		switch {
+		case err == io.EOF:
+			if len(buf) == 0 {
+				return "", io.EOF
+			}
+			_, tok, _ := in.scanArgs(buf, true)
+			return string(tok), nil
+		case err != nil:
+			return string(buf), err
+		}
+	}
+}
+
+// maxDelimLen returns the length in bytes of the longest delimiter recognized
+// by scanArgs, or 0 if there is none.
+func (in *Input) maxDelimLen() int {
+	n := len(in.ArgsDelim)
+	for _, d := range in.MultiDelim {
+		n = max(n, len(d))
+	}
+	if len(in.DelimRunes) > 0 {
+		n = max(n, utf8.UTFMax)
+	}
+	return n
+}
+
+// delimNear reports whether a delimiter, or the beginning of one, occurs within
+// the last tail bytes of buf, in which case scanArgs may be able to return a
+// token. If no delimiter is configured, it always reports true.
+func (in *Input) delimNear(buf []byte, tail int) bool {
+	if tail == 0 {
+		return true
+	}
+	for k := max(0, len(buf)-tail); k < len(buf); k++ {
+		if n, more := in.delimAt(buf[k:], false); n > 0 || more {
+			return true
+		}
+	}
+	return false
+}
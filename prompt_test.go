@@ -0,0 +1,247 @@
+package clin
+
+import (
+	"errors"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestInputLine(t *testing.T) {
+
+	in := Default()
+	in.Stream = strings.NewReader("one\ntwo\r\nthree")
+	for _, tt := range []struct {
+		want string
+		err  error
+	}{
+		{want: "one"},
+		{want: "two"},
+		{want: "three"},
+		{want: "", err: io.EOF},
+	} {
+		got, err := in.Line()
+		if !errors.Is(err, tt.err) {
+			t.Fatalf("Line() error = %v, want %v", err, tt.err)
+		}
+		if got != tt.want {
+			t.Errorf("Line() = %q, want %q", got, tt.want)
+		}
+	}
+}
+
+func TestInputLineLong(t *testing.T) {
+
+	long := strings.Repeat("x", 1<<16)
+	for _, tt := range []struct {
+		delim []byte
+		multi [][]byte
+		esc   byte
+		stdin string
+		want  []string
+	}{
+		{delim: []byte("\n"), stdin: long + "\r\nnext", want: []string{long, "next"}},
+		{delim: []byte("::"), esc: '\\', stdin: long + `\::` + long + "::end",
+			want: []string{long + "::" + long, "end"}},
+		{delim: []byte("ab"), multi: [][]byte{[]byte("abcd")}, stdin: "xabce",
+			want: []string{"x"}},
+		{delim: []byte("ab"), multi: [][]byte{[]byte("abcd")}, stdin: "xabcdy",
+			want: []string{"x", "y"}},
+	} {
+		in := Default()
+		in.ArgsDelim = tt.delim
+		in.MultiDelim = tt.multi
+		in.EscapeChar = tt.esc
+		in.Stream = strings.NewReader(tt.stdin)
+		for _, want := range tt.want {
+			if got, err := in.Line(); err != nil || got != want {
+				t.Errorf("Line() = %.20q (%d bytes), %v, want %.20q (%d bytes), nil",
+					got, len(got), err, want, len(want))
+			}
+		}
+	}
+}
+
+func TestInputLineCollapseDelims(t *testing.T) {
+
+	in := Default()
+	in.CollapseDelims = true
+	in.Stream = strings.NewReader("\n\none\r\n\r\ntwo")
+	for _, want := range []string{"one", "two"} {
+		if got, err := in.Line(); err != nil || got != want {
+			t.Errorf("Line() = %q, %v, want %q, nil", got, err, want)
+		}
+	}
+	if _, err := in.Line(); err != io.EOF {
+		t.Errorf("Line() error = %v, want %v", err, io.EOF)
+	}
+}
+
+func TestInputPrompt(t *testing.T) {
+
+	var out strings.Builder
+
+	in := Default()
+	in.Stream = strings.NewReader("alice\r\n\nbob")
+	in.Out = &out
+
+	for _, tt := range []struct {
+		label string
+		want  string
+		err   error
+	}{
+		{label: "first: ", want: "alice"},
+		{label: "second: ", want: ""},
+		{label: "third: ", want: "bob"},
+		{label: "fourth: ", want: "", err: io.EOF},
+	} {
+		got, err := in.Prompt(tt.label)
+		if !errors.Is(err, tt.err) {
+			t.Fatalf("Prompt(%q) error = %v, want %v", tt.label, err, tt.err)
+		}
+		if got != tt.want {
+			t.Errorf("Prompt(%q) = %q, want %q", tt.label, got, tt.want)
+		}
+	}
+	if got, want := out.String(), "first: second: third: fourth: "; got != want {
+		t.Errorf("Out = %q, want %q", got, want)
+	}
+}
+
+func TestInputPassword(t *testing.T) {
+
+	var out strings.Builder
+
+	// Stream is not a terminal, so the line is read as-is.
+	in := Default()
+	in.Stream = strings.NewReader("s3cret\nnext\n")
+	in.Out = &out
+	got, err := in.Password("password: ")
+	if err != nil {
+		t.Fatalf("Password() error = %v", err)
+	}
+	if got != "s3cret" {
+		t.Errorf("Password() = %q, want %q", got, "s3cret")
+	}
+	if got, want := out.String(), "password: "; got != want {
+		t.Errorf("Out = %q, want %q", got, want)
+	}
+	if got, _ := in.Line(); got != "next" {
+		t.Errorf("Line() after Password() = %q, want %q", got, "next")
+	}
+
+	// A character device that is not a terminal.
+	f, err := os.Open(os.DevNull)
+	if err != nil {
+		t.Skip(err)
+	}
+	defer f.Close()
+	in.Stream = f
+	if _, err := in.Password(""); err != io.EOF {
+		t.Errorf("Password(%s) error = %v, want %v", os.DevNull, err, io.EOF)
+	}
+}
+
+func TestInputConfirm(t *testing.T) {
+
+	for _, tt := range []struct {
+		stdin string
+		def   bool
+		want  bool
+		err   error
+		out   string
+	}{
+		{stdin: "y\n", def: false, want: true, out: "ok? [y/N] "},
+		{stdin: " YES \n", def: false, want: true, out: "ok? [y/N] "},
+		{stdin: "no\n", def: true, want: false, out: "ok? [Y/n] "},
+		{stdin: "\n", def: true, want: true, out: "ok? [Y/n] "},
+		{stdin: "\n", def: false, want: false, out: "ok? [y/N] "},
+		{stdin: "maybe\nN\n", def: true, want: false, out: "ok? [Y/n] ok? [Y/n] "},
+		{stdin: "a\nb\nc\ny\n", def: true, want: true, err: ErrInvalidAnswer,
+			out: "ok? [Y/n] ok? [Y/n] ok? [Y/n] "},
+		{stdin: "", def: true, want: true, err: io.EOF, out: "ok? [Y/n] "},
+	} {
+		var out strings.Builder
+		in := Default()
+		in.Stream = strings.NewReader(tt.stdin)
+		in.Out = &out
+		got, err := in.Confirm("ok?", tt.def)
+		if !errors.Is(err, tt.err) {
+			t.Errorf("Confirm(%q) error = %v, want %v", tt.stdin, err, tt.err)
+		}
+		if got != tt.want {
+			t.Errorf("Confirm(%q) = %t, want %t", tt.stdin, got, tt.want)
+		}
+		if out.String() != tt.out {
+			t.Errorf("Confirm(%q): Out = %q, want %q", tt.stdin, out.String(), tt.out)
+		}
+	}
+}
+
+func TestInputSelect(t *testing.T) {
+
+	options := []string{"red", "green", "blue"}
+	const list = "1) red\n2) green\n3) blue\n"
+	for _, tt := range []struct {
+		stdin string
+		index int
+		value string
+		err   error
+		out   string
+	}{
+		{stdin: "2\n", index: 1, value: "green", out: list + "? "},
+		{stdin: " 3 \n", index: 2, value: "blue", out: list + "? "},
+		{stdin: "0\n4\n1\n", index: 0, value: "red", out: list + "? ? ? "},
+		{stdin: "two\n-1\n\n2\n", index: -1, err: ErrInvalidAnswer, out: list + "? ? ? "},
+		{stdin: "", index: -1, err: io.EOF, out: list + "? "},
+	} {
+		var out strings.Builder
+		in := Default()
+		in.Stream = strings.NewReader(tt.stdin)
+		in.Out = &out
+		index, value, err := in.Select("? ", options)
+		if !errors.Is(err, tt.err) {
+			t.Errorf("Select(%q) error = %v, want %v", tt.stdin, err, tt.err)
+		}
+		if index != tt.index || value != tt.value {
+			t.Errorf("Select(%q) = %d, %q, want %d, %q",
+				tt.stdin, index, value, tt.index, tt.value)
+		}
+		if out.String() != tt.out {
+			t.Errorf("Select(%q): Out = %q, want %q", tt.stdin, out.String(), tt.out)
+		}
+	}
+
+	in := Default()
+	if _, _, err := in.Select("? ", nil); !errors.Is(err, ErrInvalidAnswer) {
+		t.Errorf("Select(nil) error = %v, want %v", err, ErrInvalidAnswer)
+	}
+}
+
+func TestInputReadUntil(t *testing.T) {
+
+	in := Default()
+	in.TrimSpace = true
+	in.Stream = strings.NewReader("first\r\n\n  second \n EOF \nafter\n")
+	got, err := in.ReadUntil("EOF")
+	if err != nil {
+		t.Fatalf("ReadUntil() error = %v", err)
+	}
+	if want := []string{"first", "", "second"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ReadUntil() = %q, want %q", got, want)
+	}
+	if rest, err := in.Line(); err != nil || rest != "after" {
+		t.Errorf("Line() after ReadUntil() = %q, %v, want %q, nil", rest, err, "after")
+	}
+
+	in.Stream = strings.NewReader("a\nb")
+	got, err = in.ReadUntil("EOF")
+	if !errors.Is(err, ErrMissingSentinel) {
+		t.Errorf("ReadUntil(missing) error = %v, want %v", err, ErrMissingSentinel)
+	}
+	if want := []string{"a", "b"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ReadUntil(missing) = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,101 @@
+package clin
+
+import (
+	"errors"
+)
+
+// ErrUnterminatedQuote is returned when ShellSplit is true and Stream ends
+// inside of a quoted string.
+var ErrUnterminatedQuote = errors.New("clin: unterminated quoted string")
+
+// scanShell is a bufio.SplitFunc that tokenizes data using a subset of the
+// POSIX shell quoting rules:
+//
+//   - Tokens are separated by unquoted spaces, tabs, CRs, and newlines.
+//   - Characters enclosed in single quotes ('…') are preserved literally.
+//   - Characters enclosed in double quotes ("…") are preserved literally,
+//     except a backslash, which escapes a following $, `, ", \, or newline.
+//   - Outside of quotes, a backslash preserves the literal value of the
+//     following character, except a newline, which is removed entirely (line
+//     continuation).
+//
+// Quotes are removed from each token, and adjacent quoted and unquoted strings
+// that are not separated by white space are concatenated into a single token.
+func scanShell(data []byte, atEOF bool) (int, []byte, error) {
+
+	start := 0
+	for start < len(data) && isShellSpace(data[start]) {
+		start++
+	}
+	tok := []byte{}
+	quote := byte(0)
+	// Whether a quote or character has begun a token, so that a line
+	// continuation alone (e.g., between blanks) does not produce one.
+	word := false
+	for i := start; i < len(data); i++ {
+		c := data[i]
+		switch {
+		case quote == '\'':
+			if c == '\'' {
+				quote = 0
+			} else {
+				tok = append(tok, c)
+			}
+		case c == '\\':
+			if i+1 == len(data) {
+				if !atEOF {
+					return 0, nil, nil
+				}
+				// Trailing backslash at EOF is preserved.
+				tok, word = append(tok, c), true
+				continue
+			}
+			i++
+			switch next := data[i]; {
+			case next == '\n':
+				// Line continuation: remove both characters.
+			case quote == '"' && !isShellEscape(next):
+				tok, word = append(tok, c, next), true
+			default:
+				tok, word = append(tok, next), true
+			}
+		case quote == '"':
+			if c == '"' {
+				quote = 0
+			} else {
+				tok = append(tok, c)
+			}
+		case c == '\'' || c == '"':
+			quote, word = c, true
+		case isShellSpace(c):
+			if !word {
+				// Only line continuations so far; keep skipping white space.
+				continue
+			}
+			return i + 1, tok, nil
+		default:
+			tok, word = append(tok, c), true
+		}
+	}
+	if !atEOF {
+		return 0, nil, nil
+	}
+	if quote != 0 {
+		return 0, nil, ErrUnterminatedQuote
+	}
+	if !word {
+		// Only white space (and line continuations) remains.
+		return len(data), nil, nil
+	}
+	return len(data), tok, nil
+}
+
+// isShellSpace reports whether c separates tokens in scanShell.
+func isShellSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
+
+// isShellEscape reports whether c is escaped by a backslash in double quotes.
+func isShellEscape(c byte) bool {
+	return c == '$' || c == '`' || c == '"' || c == '\\' || c == '\n'
+}
@@ -0,0 +1,52 @@
+package clin
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestInputShellSplit(t *testing.T) {
+
+	for _, tt := range []struct {
+		stdin string
+		want  []string
+	}{
+		{stdin: `"hello world" foo`, want: []string{"hello world", "foo"}},
+		{stdin: "  a\t b \n\n c  \n", want: []string{"a", "b", "c"}},
+		{stdin: `'say "hi"' "it's"`, want: []string{`say "hi"`, "it's"}},
+		{stdin: `escaped\ space a\\b \'q\'`, want: []string{"escaped space", `a\b`, "'q'"}},
+		{stdin: `"a \"b\" \$c \d" '\n'`, want: []string{`a "b" $c \d`, `\n`}},
+		{stdin: `pre"mid dle"'post' ""`, want: []string{"premid dlepost", ""}},
+		{stdin: "con\\\ntinued \"line\\\nbreak\"", want: []string{"continued", "linebreak"}},
+		{stdin: `trailing\`, want: []string{`trailing\`}},
+		{stdin: " \t\n", want: []string{}},
+		{stdin: "a \\\n b", want: []string{"a", "b"}},
+		{stdin: "\\\n", want: []string{}},
+		{stdin: "\\\n\\\n\tx\\\n", want: []string{"x"}},
+		{stdin: `'' "" '\\'`, want: []string{"", "", `\\`}},
+		{stdin: "a \\\n''", want: []string{"a", ""}},
+	} {
+		in := Default()
+		in.ShellSplit = true
+		in.Stream = iotest.OneByteReader(strings.NewReader(tt.stdin))
+		got, err := in.ArgsErr([]string{})
+		if err != nil {
+			t.Fatalf("ArgsErr(%q) error = %v", tt.stdin, err)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ArgsErr(%q) = %q, want %q", tt.stdin, got, tt.want)
+		}
+	}
+
+	for _, stdin := range []string{`a "b c`, `a 'b`, `"a\"`} {
+		in := Default()
+		in.ShellSplit = true
+		in.Stream = strings.NewReader(stdin)
+		if _, err := in.ArgsErr([]string{}); !errors.Is(err, ErrUnterminatedQuote) {
+			t.Errorf("ArgsErr(%q) error = %v, want %v", stdin, err, ErrUnterminatedQuote)
+		}
+	}
+}
@@ -0,0 +1,16 @@
+//go:build !(linux || darwin || dragonfly || freebsd || netbsd || openbsd)
+
+package clin
+
+import (
+	"io"
+	"os"
+)
+
+// isTerminal reports whether f is a terminal. On this platform, it is the same
+// as IsTerminal, so other character devices are also reported as terminals.
+func isTerminal(f *os.File) bool { return IsTerminal(f) }
+
+// noEcho does nothing and returns a nil function on this platform, where
+// disabling terminal echo is not supported.
+func noEcho(io.Reader) (restore func() error, err error) { return nil, nil }
@@ -0,0 +1,64 @@
+//go:build linux || darwin || dragonfly || freebsd || netbsd || openbsd
+
+package clin
+
+import (
+	"io"
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// isTerminal reports whether f is a terminal, as determined by the termios
+// ioctl, so that other character devices (e.g., os.DevNull) are not.
+func isTerminal(f *os.File) bool {
+	if !IsTerminal(f) {
+		return false
+	}
+	sc, err := f.SyscallConn()
+	if err != nil {
+		return false
+	}
+	var t syscall.Termios
+	var terr error
+	if err := sc.Control(func(fd uintptr) {
+		terr = termios(fd, ioctlGetTermios, &t)
+	}); err != nil {
+		return false
+	}
+	return terr == nil
+}
+
+// noEcho disables echo on the terminal referred to by r, and returns a function
+// that restores its previous state.
+// If r is not a terminal, noEcho does nothing and returns a nil function.
+func noEcho(r io.Reader) (restore func() error, err error) {
+	f, ok := r.(*os.File)
+	if !ok || !IsTerminal(f) {
+		return nil, nil
+	}
+	fd := f.Fd()
+	var old syscall.Termios
+	if termios(fd, ioctlGetTermios, &old) != nil {
+		// A character device that is not a terminal (e.g., /dev/null).
+		return nil, nil
+	}
+	t := old
+	t.Lflag &^= syscall.ECHO
+	t.Lflag |= syscall.ICANON | syscall.ISIG
+	t.Iflag |= syscall.ICRNL
+	if err := termios(fd, ioctlSetTermios, &t); err != nil {
+		return nil, err
+	}
+	return func() error { return termios(fd, ioctlSetTermios, &old) }, nil
+}
+
+// termios performs the ioctl request req with t on the terminal fd.
+func termios(fd uintptr, req uintptr, t *syscall.Termios) error {
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, fd, req,
+		uintptr(unsafe.Pointer(t)))
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
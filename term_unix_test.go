@@ -0,0 +1,57 @@
+//go:build linux || darwin || dragonfly || freebsd || netbsd || openbsd
+
+package clin
+
+import (
+	"errors"
+	"io"
+	"os"
+	"testing"
+)
+
+func TestInputErrorOnTTY(t *testing.T) {
+
+	// A character device that is not a terminal is read normally.
+	dev, err := os.Open(os.DevNull)
+	if err != nil {
+		t.Skipf("cannot open %s: %v", os.DevNull, err)
+	}
+	defer dev.Close()
+	in := Default()
+	in.ErrorOnTTY = true
+	in.Stream = dev
+	if _, err := in.ArgsErr([]string{}); err != nil {
+		t.Errorf("%s: ArgsErr() error = %v, want nil", os.DevNull, err)
+	}
+
+	// The master side of a pseudo-terminal is a terminal.
+	ptm, err := os.OpenFile("/dev/ptmx", os.O_RDWR, 0)
+	if err != nil {
+		t.Skipf("cannot open /dev/ptmx: %v", err)
+	}
+	defer ptm.Close()
+	if !isTerminal(ptm) {
+		t.Skip("/dev/ptmx is not a terminal")
+	}
+	in.Stream = ptm
+	in.ErrorOnTTY = false
+	if got := in.Source([]string{}); got != SourceStream {
+		t.Errorf("ErrorOnTTY=false: Source() = %v, want %v", got, SourceStream)
+	}
+	in.ErrorOnTTY = true
+	if _, err := in.ArgsErr([]string{}); !errors.Is(err, ErrTerminal) {
+		t.Errorf("ErrorOnTTY=true: ArgsErr() error = %v, want %v", err, ErrTerminal)
+	}
+	if _, err := in.ReaderCloser([]string{}); !errors.Is(err, ErrTerminal) {
+		t.Errorf("ErrorOnTTY=true: ReaderCloser() error = %v, want %v", err, ErrTerminal)
+	}
+	if got := in.Args([]string{}); len(got) != 0 {
+		t.Errorf("ErrorOnTTY=true: Args() = %q, want []", got)
+	}
+	if b, err := io.ReadAll(in.Reader([]string{})); err != nil || len(b) != 0 {
+		t.Errorf("ErrorOnTTY=true: Reader() = %q, %v, want \"\", nil", b, err)
+	}
+	if a, err := in.ArgsErr([]string{"arg"}); err != nil || len(a) != 1 {
+		t.Errorf("ErrorOnTTY=true: ArgsErr(arg) = %q, %v, want [arg], nil", a, err)
+	}
+}